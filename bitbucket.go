@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Bitbucket Cloud's API host, any other host is treated as Bitbucket Server
+// (Data Center)
+const bitbucketCloudHost = "api.bitbucket.org"
+
+// Environment variable holding an access token, sent as a bearer token
+const bitbucketTokenEnv = "BITBUCKET_TOKEN"
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// Where to read commits from on Bitbucket. Repo is "workspace/slug" on Cloud
+// and "PROJECT/slug" on Server.
+type BitbucketSource struct {
+	Host string
+	Repo string
+	User string
+}
+
+// Page of commits from Bitbucket Cloud's /2.0/repositories/{repo}/commits
+type bitbucketCloudPage struct {
+	Values []struct {
+		Hash    string    `json:"hash"`
+		Date    time.Time `json:"date"`
+		Message string    `json:"message"`
+		Author  struct {
+			Raw  string `json:"raw"` // "Name <email>"
+			User struct {
+				DisplayName string `json:"display_name"`
+				Nickname    string `json:"nickname"`
+			} `json:"user"`
+		} `json:"author"`
+	} `json:"values"`
+	Next string `json:"next"`
+}
+
+// Page of commits from Bitbucket Server's /rest/api/1.0 commits endpoint
+type bitbucketServerPage struct {
+	Values []struct {
+		DisplayID string `json:"displayId"`
+		Message   string `json:"message"`
+		Author    struct {
+			Name         string `json:"name"`
+			DisplayName  string `json:"displayName"`
+			EmailAddress string `json:"emailAddress"`
+		} `json:"author"`
+		AuthorTimestamp int64 `json:"authorTimestamp"` // Milliseconds since the epoch
+	} `json:"values"`
+	IsLastPage    bool `json:"isLastPage"`
+	NextPageStart int  `json:"nextPageStart"`
+}
+
+// GET a Bitbucket API URL and decode the JSON response into v
+func bitbucketGet(rawURL string, v any) error {
+	req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+	if err != nil {
+		return err
+	}
+	if token := os.Getenv(bitbucketTokenEnv); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("bitbucket returned %s for %s", resp.Status, rawURL)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// Whether user names the commit author by account name, display name or
+// the raw "Name <email>" string
+func bitbucketAuthorMatches(user string, names ...string) bool {
+	for _, name := range names {
+		if name != "" && strings.Contains(strings.ToLower(name), strings.ToLower(user)) {
+			return true
+		}
+	}
+	return false
+}
+
+// First line of a commit message, Bitbucket only returns the full message
+func subjectOf(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
+
+// Fetch the user's commits in the repo, newest first. Pages are followed
+// until a commit older than since shows up, pass the zero time to read the
+// whole history.
+func fetchBitbucket(src BitbucketSource, since time.Time) (CommitHistory, error) {
+	owner, slug, ok := strings.Cut(src.Repo, "/")
+	if !ok || owner == "" || slug == "" {
+		return CommitHistory{}, fmt.Errorf("--repo must look like workspace/repo, got %q", src.Repo)
+	}
+
+	var commits []Commit
+	var err error
+	if src.Host == bitbucketCloudHost {
+		commits, err = fetchBitbucketCloud(src, owner, slug, since)
+	} else {
+		commits, err = fetchBitbucketServer(src, owner, slug, since)
+	}
+	if err != nil {
+		return CommitHistory{}, err
+	}
+	if len(commits) == 0 {
+		return CommitHistory{}, errNoCommits
+	}
+	return CommitHistory{Author: src.User, Filter: LogFilter{Author: src.User}, Commits: commits}, nil
+}
+
+func fetchBitbucketCloud(src BitbucketSource, workspace, slug string, since time.Time) ([]Commit, error) {
+	next := fmt.Sprintf("https://%s/2.0/repositories/%s/%s/commits?pagelen=100",
+		src.Host, url.PathEscape(workspace), url.PathEscape(slug))
+	commits := make([]Commit, 0)
+	for next != "" {
+		var page bitbucketCloudPage
+		if err := bitbucketGet(next, &page); err != nil {
+			return nil, err
+		}
+		for _, value := range page.Values {
+			if value.Date.Before(since) {
+				return commits, nil
+			}
+			author := value.Author
+			if !bitbucketAuthorMatches(src.User, author.User.Nickname, author.User.DisplayName, author.Raw) {
+				continue
+			}
+			name, email, _ := strings.Cut(strings.TrimSuffix(author.Raw, ">"), " <")
+			commits = append(commits, Commit{
+				Hash:        value.Hash[:min(7, len(value.Hash))],
+				Author:      name,
+				AuthorEmail: email,
+				Subject:     subjectOf(value.Message),
+				Timestamp:   value.Date,
+			})
+		}
+		next = page.Next
+	}
+	return commits, nil
+}
+
+func fetchBitbucketServer(src BitbucketSource, project, slug string, since time.Time) ([]Commit, error) {
+	base := fmt.Sprintf("https://%s/rest/api/1.0/projects/%s/repos/%s/commits",
+		src.Host, url.PathEscape(project), url.PathEscape(slug))
+	commits := make([]Commit, 0)
+	start := 0
+	for {
+		var page bitbucketServerPage
+		if err := bitbucketGet(fmt.Sprintf("%s?limit=100&start=%d", base, start), &page); err != nil {
+			return nil, err
+		}
+		for _, value := range page.Values {
+			date := time.UnixMilli(value.AuthorTimestamp)
+			if date.Before(since) {
+				return commits, nil
+			}
+			author := value.Author
+			if !bitbucketAuthorMatches(src.User, author.Name, author.DisplayName, author.EmailAddress) {
+				continue
+			}
+			commits = append(commits, Commit{
+				Hash:        value.DisplayID,
+				Author:      author.DisplayName,
+				AuthorEmail: author.EmailAddress,
+				Subject:     subjectOf(value.Message),
+				Timestamp:   date,
+			})
+		}
+		if page.IsLastPage {
+			return commits, nil
+		}
+		start = page.NextPageStart
+	}
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// Sends every request to server whatever host it's for, so the Cloud and
+// Server URLs can be answered locally
+type redirectTransport struct{ server *httptest.Server }
+
+func (t redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = target.Scheme, target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// Serve pages from handler for the rest of the test, returns the requested
+// URLs with their query
+func fakeBitbucket(t *testing.T, pages func(r *http.Request) any) *[]string {
+	t.Helper()
+	requests := new([]string)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.RequestURI())
+		json.NewEncoder(w).Encode(pages(r))
+	}))
+	t.Cleanup(server.Close)
+	original := httpClient
+	httpClient = &http.Client{Transport: redirectTransport{server}}
+	t.Cleanup(func() { httpClient = original })
+	return requests
+}
+
+// A Bitbucket Cloud commit as the API returns it
+func cloudCommit(hash, raw, nickname string, date time.Time) map[string]any {
+	return map[string]any{
+		"hash": hash, "date": date.Format(time.RFC3339), "message": "Subject of " + hash + "\n\nBody",
+		"author": map[string]any{"raw": raw, "user": map[string]any{"nickname": nickname}},
+	}
+}
+
+// A Bitbucket Server commit as the API returns it
+func serverCommit(id, name string, date time.Time) map[string]any {
+	return map[string]any{
+		"displayId": id, "message": "Subject of " + id, "authorTimestamp": date.UnixMilli(),
+		"author": map[string]any{"name": name, "displayName": name, "emailAddress": name + "@example.com"},
+	}
+}
+
+func TestBitbucketCloudFollowsNextUntilSince(t *testing.T) {
+	now := time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC)
+	requests := fakeBitbucket(t, func(r *http.Request) any {
+		switch r.URL.Query().Get("page") {
+		case "":
+			return map[string]any{
+				"values": []any{
+					cloudCommit("aaaaaaaaaa", "Jane Doe <jane@example.com>", "jane", now),
+					cloudCommit("bbbbbbbbbb", "Bob <bob@example.com>", "bob", now.AddDate(0, 0, -1)),
+				},
+				"next": "https://api.bitbucket.org/2.0/repositories/team/app/commits?pagelen=100&page=2",
+			}
+		case "2":
+			return map[string]any{
+				"values": []any{
+					cloudCommit("cccccccccc", "Jane Doe <jane@example.com>", "jane", now.AddDate(0, 0, -2)),
+					// Older than since, nothing after it is read
+					cloudCommit("dddddddddd", "Jane Doe <jane@example.com>", "jane", now.AddDate(0, 0, -30)),
+				},
+				"next": "https://api.bitbucket.org/2.0/repositories/team/app/commits?pagelen=100&page=3",
+			}
+		}
+		t.Errorf("requested %s after reaching since", r.URL)
+		return map[string]any{}
+	})
+
+	src := BitbucketSource{Host: bitbucketCloudHost, Repo: "team/app", User: "jane"}
+	history, err := fetchBitbucket(src, now.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 2 {
+		t.Errorf("made %d requests, want 2: %v", len(*requests), *requests)
+	}
+	if len(history.Commits) != 2 {
+		t.Fatalf("got %d commits, want 2", len(history.Commits))
+	}
+	commit := history.Commits[0]
+	if commit.Hash != "aaaaaaa" || commit.Author != "Jane Doe" || commit.AuthorEmail != "jane@example.com" || commit.Subject != "Subject of aaaaaaaaaa" {
+		t.Errorf("first commit = %+v", commit)
+	}
+	if history.Commits[1].Hash != "ccccccc" {
+		t.Errorf("second commit = %s, want ccccccc", history.Commits[1].Hash)
+	}
+}
+
+func TestBitbucketServerFollowsNextPageStartUntilSince(t *testing.T) {
+	now := time.Date(2026, 7, 10, 12, 0, 0, 0, time.UTC)
+	requests := fakeBitbucket(t, func(r *http.Request) any {
+		switch r.URL.Query().Get("start") {
+		case "0":
+			return map[string]any{
+				"values":        []any{serverCommit("a1", "jane", now), serverCommit("b1", "bob", now)},
+				"isLastPage":    false,
+				"nextPageStart": 2,
+			}
+		case "2":
+			return map[string]any{
+				"values":        []any{serverCommit("a2", "jane", now.AddDate(0, 0, -3)), serverCommit("a3", "jane", now.AddDate(0, 0, -30))},
+				"isLastPage":    false,
+				"nextPageStart": 4,
+			}
+		}
+		t.Errorf("requested %s after reaching since", r.URL)
+		return map[string]any{"isLastPage": true}
+	})
+
+	src := BitbucketSource{Host: "bitbucket.example.com", Repo: "TEAM/app", User: "jane"}
+	history, err := fetchBitbucket(src, now.AddDate(0, 0, -7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*requests) != 2 || (*requests)[0] != "/rest/api/1.0/projects/TEAM/repos/app/commits?limit=100&start=0" {
+		t.Errorf("requests = %v, want start=0 and start=2", *requests)
+	}
+	if len(history.Commits) != 2 || history.Commits[0].Hash != "a1" || history.Commits[1].Hash != "a2" {
+		t.Errorf("commits = %+v, want a1 and a2", history.Commits)
+	}
+}
+
+func TestBitbucketServerStopsOnLastPage(t *testing.T) {
+	requests := fakeBitbucket(t, func(r *http.Request) any {
+		return map[string]any{"values": []any{serverCommit("b1", "bob", time.Now())}, "isLastPage": true}
+	})
+	src := BitbucketSource{Host: "bitbucket.example.com", Repo: "TEAM/app", User: "jane"}
+	if _, err := fetchBitbucket(src, time.Time{}); err != errNoCommits {
+		t.Errorf("fetchBitbucket error = %v, want %v", err, errNoCommits)
+	}
+	if len(*requests) != 1 {
+		t.Errorf("made %d requests, want 1", len(*requests))
+	}
+}
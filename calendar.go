@@ -0,0 +1,36 @@
+package main
+
+import "time"
+
+// One window's calendar, computed once and read by every --format written.
+// The stats aren't part of it, they cover every window together.
+type Calendar struct {
+	History   CommitHistory // Everything fetched, for the filter and tags
+	Window    Window
+	Opts      RenderOptions // Scaled for the window
+	Commits   []Commit      // The ones in the window
+	Total     int           // Commits in the window by their weight
+	Matrix    [][]int       // One cell per day, or per week with --granularity week
+	GridStart time.Time
+	Deltas    [][]int // Change of each day against the period before, only with --diff
+	Previous  int     // Commits of the period before by their weight, only with --diff
+}
+
+func newCalendar(history CommitHistory, window Window, opts RenderOptions) Calendar {
+	commits := commitsInWindow(history.Commits, window.Start, window.End)
+	matrix, gridStart := buildMatrix(commits, window, opts.Metric)
+	if opts.Weekly {
+		matrix = weekSums(matrix)
+		// A cell is no single day to mark or link to
+		opts.TagDays, opts.Excluded, opts.LinkURL = nil, nil, ""
+	}
+	return Calendar{
+		History:   history,
+		Window:    window,
+		Opts:      opts,
+		Commits:   commits,
+		Total:     weightedTotal(commits),
+		Matrix:    matrix,
+		GridStart: gridStart,
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+)
+
+// Width of the longest bar in a chart
+const chartWidth = 40
+
+// One bar per weekday starting at weekStart, scaled so the busiest weekday
+// gets the full chartWidth, each labelled with its commit count
+func weekdayChart(commits []Commit, opts RenderOptions) string {
+	counts := weekdayCounts(commits)
+	busiest := 0
+	for _, count := range counts {
+		busiest = max(busiest, count)
+	}
+
+	labels, values := make([]string, rows), make([]int, rows)
+	for i := range rows {
+		day := (weekStart + time.Weekday(i)) % 7
+		labels[i], values[i] = opts.weekdayName(day), counts[day]
+	}
+	return barChart(labels, values)
+}
+
+// Commit sizes for --breakdown commit-size, in lines added plus deleted.
+// Each bucket holds sizes below its limit and above the previous one's.
+var sizeBuckets = []struct {
+	label string
+	limit int
+}{
+	{"< 10", 10},
+	{"10–99", 100},
+	{"100–999", 1000},
+	{"1000+", math.MaxInt},
+}
+
+// How many commits fall in each of sizeBuckets, plus a bucket for commits
+// that only touched binary files since those have no line counts
+func commitSizeChart(commits []Commit) string {
+	labels := make([]string, 0, len(sizeBuckets)+1)
+	for _, bucket := range sizeBuckets {
+		labels = append(labels, bucket.label)
+	}
+	labels = append(labels, "binary only")
+
+	counts := make([]int, len(labels))
+	for _, commit := range commits {
+		if commit.FilesChanged > 0 && commit.BinaryFiles == commit.FilesChanged {
+			counts[len(sizeBuckets)]++
+			continue
+		}
+		size := commit.Additions + commit.Deletions
+		for i, bucket := range sizeBuckets {
+			if size < bucket.limit {
+				counts[i]++
+				break
+			}
+		}
+	}
+	return barChart(labels, counts)
+}
+
+// One labelled bar per value, scaled so the largest gets the full
+// chartWidth, each followed by its value
+func barChart(labels []string, values []int) string {
+	largest, labelWidth := 0, 0
+	for i, value := range values {
+		largest = max(largest, value)
+		labelWidth = max(labelWidth, utf8.RuneCountInString(labels[i]))
+	}
+
+	bar := color.New(color.FgGreen)
+	chart := ""
+	for i, value := range values {
+		width := 0
+		if largest > 0 {
+			width = value * chartWidth / largest
+		}
+		// Any commits at all get at least a sliver of a bar
+		if width == 0 && value > 0 {
+			width = 1
+		}
+		// Pad the labels and bars so they line up
+		label := labels[i] + strings.Repeat(" ", labelWidth-utf8.RuneCountInString(labels[i]))
+		chart += fmt.Sprintf("%s %s%s %d\n", label,
+			bar.Sprint(strings.Repeat("█", width)), strings.Repeat(" ", chartWidth-width), value)
+	}
+	return chart
+}
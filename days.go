@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Subjects listed per day by --top-days, --day shows all of them
+const topDaySubjects = 3
+
+// Commits grouped by day
+type dayCommits struct {
+	Day     time.Time
+	Commits []Commit
+	Count   int // Commits by their weight, see dailyCounts
+}
+
+// The n days with the most commits by their weight, busiest first. Of days
+// with as many commits the more recent one comes first.
+func topDays(commits []Commit, n int) []dayCommits {
+	byDay := make(map[time.Time][]Commit)
+	for _, commit := range commits {
+		day := dayOf(commit.Timestamp)
+		byDay[day] = append(byDay[day], commit)
+	}
+	counts := dailyCounts(commits)
+	days := make([]dayCommits, 0, len(byDay))
+	for day, dayCommitList := range byDay {
+		days = append(days, dayCommits{day, dayCommitList, counts[day]})
+	}
+	sort.Slice(days, func(i, j int) bool {
+		if days[i].Count != days[j].Count {
+			return days[i].Count > days[j].Count
+		}
+		return days[i].Day.After(days[j].Day)
+	})
+	return days[:min(n, len(days))]
+}
+
+// List of the busiest days for --top-days with the first few subjects of
+// each, pointing to --day for the rest
+func topDaysList(commits []Commit, n int, opts RenderOptions) string {
+	days := topDays(commits, n)
+	if len(days) == 0 {
+		return "No commits in the selected window.\n"
+	}
+	list := ""
+	for i, day := range days {
+		list += fmt.Sprintf("%2d. %s  %s\n", i+1, opts.dayName(day.Day), plural(day.Count, "commit"))
+		for _, commit := range day.Commits[:min(topDaySubjects, len(day.Commits))] {
+			list += fmt.Sprintf("      %s %s\n", commit.Hash, commit.Subject)
+		}
+		if more := len(day.Commits) - topDaySubjects; more > 0 {
+			list += fmt.Sprintf("      ...and %d more, see --day %s\n", more, day.Day.Format(time.DateOnly))
+		}
+	}
+	return list
+}
+
+// One line about a day for --footer-date, e.g. "Thu Mar 14, 2024 — 23
+// commits", in the unit of the metric
+func footerLine(commits []Commit, day time.Time, opts RenderOptions) string {
+	value := dailyValues(commits, opts.Metric)[dayOf(day)]
+	unit := "commit"
+	if opts.Metric == metricLines {
+		unit = "line"
+	}
+	return opts.encode(opts.dayName(day) + " — " + plural(value, unit))
+}
+
+// Every commit of a day for --day, with its time. Merges are listed even
+// when they don't count, the header has the weighted count.
+func dayList(commits []Commit, day time.Time, opts RenderOptions) string {
+	dayCommitList := make([]Commit, 0)
+	for _, commit := range commits {
+		if dayOf(commit.Timestamp).Equal(dayOf(day)) {
+			dayCommitList = append(dayCommitList, commit)
+		}
+	}
+	list := fmt.Sprintf("%s  %s\n", opts.dayName(day), plural(weightedTotal(dayCommitList), "commit"))
+	for _, commit := range dayCommitList {
+		list += fmt.Sprintf("  %s %s %s\n", commit.Hash, commit.Timestamp.Format("15:04"), commit.Subject)
+	}
+	return list
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// Three commits on 07-01, two of them merges, and two plain ones on 07-02
+func mergeDays(t *testing.T, mergeWeight float64) []Commit {
+	t.Helper()
+	commits := commitsOn(t, "2026-07-01", "2026-07-01", "2026-07-01", "2026-07-02", "2026-07-02")
+	commits[1].Parents, commits[2].Parents = 2, 2
+	weighMerges(commits, mergeWeight)
+	return commits
+}
+
+func TestTopDaysByWeight(t *testing.T) {
+	tests := []struct {
+		merges string
+		first  string
+		counts []int
+	}{
+		{"full", "2026-07-01", []int{3, 2}},
+		{"half", "2026-07-02", []int{2, 2}}, // A tie, the newer day comes first
+		{"zero", "2026-07-02", []int{2, 1}},
+	}
+	for _, test := range tests {
+		days := topDays(mergeDays(t, mergeWeights[test.merges]), 2)
+		if len(days) != 2 {
+			t.Fatalf("%s: got %d days, want 2", test.merges, len(days))
+		}
+		if first := days[0].Day.Format("2006-01-02"); first != test.first {
+			t.Errorf("%s: busiest day %s, want %s", test.merges, first, test.first)
+		}
+		if days[0].Count != test.counts[0] || days[1].Count != test.counts[1] {
+			t.Errorf("%s: counts %d and %d, want %v", test.merges, days[0].Count, days[1].Count, test.counts)
+		}
+	}
+}
+
+func TestDayListLabelsTheWeightedCount(t *testing.T) {
+	list := dayList(mergeDays(t, mergeWeights["zero"]), day(t, "2026-07-01"), RenderOptions{})
+	lines := strings.Split(strings.TrimSpace(list), "\n")
+	if !strings.HasSuffix(lines[0], " 1 commit") || len(lines) != 4 {
+		t.Errorf("dayList = %q, want a header of 1 commit and all three listed", list)
+	}
+}
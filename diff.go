@@ -0,0 +1,158 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Diverging palette for --diff, levels 1 and up of fewer commits than the
+// period before (reds) and of more (the github theme's greens)
+var (
+	diffFewer = []paletteColor{
+		{r: 0x5a, g: 0x1e, b: 0x1e, rgb: true},
+		{r: 0x8e, g: 0x24, b: 0x24, rgb: true},
+		{r: 0xc9, g: 0x3c, b: 0x3c, rgb: true},
+		{r: 0xf8, g: 0x51, b: 0x49, rgb: true},
+	}
+	diffMore = themes["github"]
+)
+
+// Length of a window in days, which is how far back the period it's
+// compared against starts with --diff
+func windowDays(window Window) int {
+	return int(dayOf(window.End).Sub(dayOf(window.Start)).Hours()/24) + 1
+}
+
+// The day --diff compares day of the window with: the same date a year
+// earlier for a calendar year, so this year so far is held against the same
+// part of last year, otherwise as many days earlier as the window is long
+func (window Window) before(day time.Time) time.Time {
+	if window.Year {
+		return day.AddDate(-1, 0, 0)
+	}
+	return day.AddDate(0, 0, -windowDays(window))
+}
+
+// The period --diff compares the window with, e.g. "the 364 days before"
+func (window Window) beforeLabel() string {
+	if window.Year {
+		return "the same days of " + strconv.Itoa(window.Start.Year()-1)
+	}
+	return "the " + plural(windowDays(window), "day") + " before"
+}
+
+// The --diff calendar of a window, with each day's change against the
+// period right before it
+func newDiffCalendar(history CommitHistory, window Window, opts RenderOptions) Calendar {
+	cal := newCalendar(history, window, opts)
+	previous := commitsInWindow(history.Commits, window.before(window.Start), window.before(window.End))
+	cal.Deltas = diffMatrix(cal.Matrix, cal.GridStart, previous, window.before, opts.Metric)
+	cal.Previous = weightedTotal(previous)
+	return cal
+}
+
+// Change of each day of a window's matrix against the day before maps it
+// to, counted from previousCommits. The deltas can't mark the slots outside
+// the window since a change of -1 is a real value, so those are read from
+// days.
+func diffMatrix(days [][]int, gridStart time.Time, previousCommits []Commit, before func(time.Time) time.Time, metric string) [][]int {
+	previous := dailyValues(previousCommits, metric)
+	deltas := make([][]int, len(days))
+	for row := range days {
+		deltas[row] = make([]int, len(days[row]))
+		for col, count := range days[row] {
+			if count == noDay {
+				continue
+			}
+			date := gridStart.AddDate(0, 0, col*rows+row)
+			deltas[row][col] = count - previous[before(date)]
+		}
+	}
+	return deltas
+}
+
+// Cell for a change, red for fewer and green for more at the level of its
+// size, unchanged days look like days without contributions
+func (opts RenderOptions) diffCell(delta int) string {
+	if delta < 0 {
+		return opts.diffLevelCell(diffFewer, opts.shownLevel(-delta))
+	}
+	return opts.diffLevelCell(diffMore, opts.shownLevel(delta))
+}
+
+// Cell for a level of one side of the diverging palette
+func (opts RenderOptions) diffLevelCell(palette []paletteColor, level int) string {
+	if level == 0 {
+		return opts.drawCell(opts.levelColor(0), 0, false)
+	}
+	return opts.drawCell(palette[(level-1)%len(palette)], level, false)
+}
+
+// Diverging legend for --diff, from the biggest drop over no change to the
+// biggest gain
+func diffLegend(opts RenderOptions) string {
+	fewer, more := make([]string, 0), make([]string, 0)
+	for level := 1; level < len(greens); level++ {
+		values := opts.levelRange(level)
+		if values == "" {
+			continue // Nothing is drawn at this level
+		}
+		shown := level
+		if shown < opts.MinLevel {
+			shown = 0 // Match how hidden levels look in the calendar
+		}
+		fewer = append([]string{opts.diffLevelCell(diffFewer, shown) + " −" + values}, fewer...)
+		more = append(more, opts.diffLevelCell(diffMore, shown)+" +"+values)
+	}
+	parts := append(append([]string{"Fewer"}, fewer...), opts.diffLevelCell(diffMore, 0)+" 0")
+	parts = append(append(parts, more...), "More")
+	return strings.Join(parts, " ") + " (change in " + opts.Metric + " a day)"
+}
+
+// The --diff calendar of a window with its legend and a line comparing the
+// totals of both periods. Without --max-count the scale follows the
+// biggest change.
+func diffText(cal Calendar) string {
+	opts, days, deltas := cal.Opts, cal.Matrix, cal.Deltas
+	if opts.MaxCount == 0 {
+		for row := range deltas {
+			for _, delta := range deltas[row] {
+				opts.MaxCount = max(opts.MaxCount, delta, -delta)
+			}
+		}
+		// Nothing changed, any scale draws the same
+		opts.MaxCount = max(opts.MaxCount, 1)
+	}
+
+	weeks := len(days[0])
+	grid := ""
+	for row := range days {
+		for col, count := range days[row] {
+			if col > 0 {
+				grid += " "
+			}
+			if count == noDay {
+				grid += opts.cell(noDay, false)
+			} else {
+				grid += opts.diffCell(deltas[row][col])
+			}
+		}
+		if row < len(days)-1 {
+			grid += "\n"
+		}
+	}
+
+	text := ""
+	if !opts.NoMonths {
+		text += monthHeader(cal.GridStart, weeks, opts) + "\n"
+	}
+	text += opts.box().Render(grid) + "\n"
+	if opts.Legend {
+		text += diffLegend(opts) + "\n"
+	}
+	text += fmt.Sprintf("%d contributions %s, %+d on %s (%s)\n",
+		cal.Total, cal.Window.Label, cal.Total-cal.Previous, cal.Window.beforeLabel(), cal.History.Filter)
+	return opts.encode(text)
+}
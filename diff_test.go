@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDiffMatrixComparesThePreviousPeriod(t *testing.T) {
+	window := Window{Start: day(t, "2026-07-01"), End: day(t, "2026-07-07")}
+	if got := windowDays(window); got != 7 {
+		t.Fatalf("windowDays = %d, want 7", got)
+	}
+	// One commit a week before 07-01, two on 07-02 against one the week
+	// before and one on 06-23 that's too early to be compared against
+	commits := commitsOn(t, "2026-06-24", "2026-06-25", "2026-07-02", "2026-07-02", "2026-06-23")
+	cal := newDiffCalendar(CommitHistory{Commits: commits}, window, RenderOptions{Metric: metricCommits})
+	days, deltas, gridStart := cal.Matrix, cal.Deltas, cal.GridStart
+	if cal.Total != 2 || cal.Previous != 2 {
+		t.Errorf("totals %d and %d before, want 2 and 2", cal.Total, cal.Previous)
+	}
+
+	want := map[string]int{"2026-07-01": -1, "2026-07-02": 1, "2026-07-03": 0}
+	found := 0
+	for row := range days {
+		for col := range days[row] {
+			date := gridStart.AddDate(0, 0, col*rows+row).Format(time.DateOnly)
+			if delta, ok := want[date]; ok {
+				found++
+				if days[row][col] == noDay || deltas[row][col] != delta {
+					t.Errorf("%s: change %d, want %d", date, deltas[row][col], delta)
+				}
+			}
+		}
+	}
+	if found != len(want) {
+		t.Errorf("found %d of the %d days in the grid", found, len(want))
+	}
+}
+
+func TestDiffComparesYearsDateByDate(t *testing.T) {
+	// This year so far, up to 07-01
+	window := yearWindows(day(t, "2026-01-01"), day(t, "2026-07-01"))[0]
+	commits := commitsOn(t, "2025-03-10", "2025-07-01", "2025-07-02", "2025-12-31", "2026-03-10", "2026-03-10")
+	cal := newDiffCalendar(CommitHistory{Commits: commits}, window, RenderOptions{Metric: metricCommits})
+
+	// Only 2025 up to 07-01 counts, not the 182 days before 2026
+	if cal.Total != 2 || cal.Previous != 2 {
+		t.Errorf("totals %d and %d before, want 2 and 2", cal.Total, cal.Previous)
+	}
+	want := map[string]int{"2026-03-10": 1, "2026-07-01": -1, "2026-01-01": 0}
+	for row := range cal.Matrix {
+		for col := range cal.Matrix[row] {
+			date := cal.GridStart.AddDate(0, 0, col*rows+row).Format(time.DateOnly)
+			if delta, ok := want[date]; ok && cal.Deltas[row][col] != delta {
+				t.Errorf("%s: change %d, want %d", date, cal.Deltas[row][col], delta)
+			}
+		}
+	}
+	if got := window.beforeLabel(); got != "the same days of 2025" {
+		t.Errorf("beforeLabel = %q", got)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import "strings"
+
+// Encodings for --encoding, auto picks one with outputIsUTF8
+const (
+	encodingAuto  = "auto"
+	encodingUTF8  = "utf-8"
+	encodingASCII = "ascii"
+)
+
+// Glyph for each level when cells are drawn in ASCII, doubled to fill a
+// cell. Even level 0 has one so quiet days don't look like padding.
+var asciiGlyphs = []rune(".-+*#")
+
+// ASCII stand-ins for the characters labels and charts use
+var asciiReplacer = strings.NewReplacer(
+	"–", "-", "—", "-", "−", "-",
+	"…", "...", "•", "*", "·", "|", "↑", "^", "↓", "v",
+	"█", "#",
+)
+
+// Text for the terminal, non-ASCII characters are swapped for ASCII ones
+// with --encoding ascii. Letters of other locales like "ä" are kept.
+func (opts RenderOptions) encode(text string) string {
+	if !opts.ASCII {
+		return text
+	}
+	return asciiReplacer.Replace(text)
+}
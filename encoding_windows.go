@@ -0,0 +1,25 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// Code page of UTF-8 on Windows
+const codePageUTF8 = 65001
+
+// Whether the console's output code page is UTF-8. Windows Terminal always
+// renders UTF-8, and output that isn't a console has no code page to go by.
+func outputIsUTF8() bool {
+	if os.Getenv("WT_SESSION") != "" {
+		return true
+	}
+	codePage, err := windows.GetConsoleOutputCP()
+	if err != nil || codePage == 0 {
+		return true
+	}
+	return codePage == codePageUTF8
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// Exit codes, also used as the "code" of --json-errors. These are stable so
+// scripts can rely on them.
+const (
+	exitError     = 1 // Anything not covered below
+	exitConfig    = 2 // Invalid flags or config file
+	exitSource    = 3 // git or the remote source failed
+	exitNoCommits = 4 // The source worked but nothing matched the filters
+)
+
+// Returned by every source when no commits match, so it can be told apart
+// from the source failing
+var errNoCommits = errors.New("no contributions found")
+
+// An error together with the exit code it maps to
+type codedError struct {
+	code int
+	err  error
+}
+
+func (e *codedError) Error() string { return e.err.Error() }
+func (e *codedError) Unwrap() error { return e.err }
+
+func configError(err error) error { return &codedError{exitConfig, err} }
+func sourceError(err error) error { return &codedError{exitSource, err} }
+
+// Exit code for an error, errNoCommits wins over the kind it was wrapped in
+func exitCodeOf(err error) int {
+	if errors.Is(err, errNoCommits) {
+		return exitNoCommits
+	}
+	var coded *codedError
+	if errors.As(err, &coded) {
+		return coded.code
+	}
+	return exitError
+}
+
+// Print an error on stderr, either for humans or as {"error": "...",
+// "code": N} with --json-errors. Never on stdout, which may be a prompt or
+// JSON.
+func reportError(err error) {
+	if !*jsonErrorsFlag {
+		fmt.Fprintf(os.Stderr, "Error %v\n", err)
+		return
+	}
+	out, _ := json.Marshal(struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}{err.Error(), exitCodeOf(err)})
+	fmt.Fprintln(os.Stderr, string(out))
+}
+
+// Report an error and exit with its code
+func fail(err error) {
+	reportError(err)
+	os.Exit(exitCodeOf(err))
+}
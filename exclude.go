@@ -0,0 +1,55 @@
+package main
+
+import "strings"
+
+// Drop commits that introduce no change, e.g. made with --allow-empty.
+// Merges are kept since git log doesn't list files for them.
+func excludeEmpty(commits []Commit) []Commit {
+	kept := make([]Commit, 0, len(commits))
+	for _, commit := range commits {
+		if commit.FilesChanged == 0 && commit.Parents <= 1 {
+			continue
+		}
+		kept = append(kept, commit)
+	}
+	return kept
+}
+
+// Subject of the commit reverted by a `git revert` style subject, e.g.
+// `Revert "Add feature"` returns "Add feature"
+func revertedSubject(subject string) (string, bool) {
+	const prefix = `Revert "`
+	if !strings.HasPrefix(subject, prefix) || !strings.HasSuffix(subject, `"`) || len(subject) <= len(prefix) {
+		return "", false
+	}
+	return subject[len(prefix) : len(subject)-1], true
+}
+
+// Drop reverts along with the commit they revert so undone work doesn't
+// count. This is a best effort match on git revert's default subject: the
+// reverted commit is the closest older commit with the quoted subject. A
+// revert whose original isn't in the set (another author, reworded subject)
+// is kept.
+func excludeReverts(commits []Commit) []Commit {
+	// Commits are newest first, so an original is always after its revert
+	dropped := make([]bool, len(commits))
+	for i, commit := range commits {
+		original, ok := revertedSubject(commit.Subject)
+		if !ok || dropped[i] {
+			continue
+		}
+		for j := i + 1; j < len(commits); j++ {
+			if !dropped[j] && commits[j].Subject == original {
+				dropped[i], dropped[j] = true, true
+				break
+			}
+		}
+	}
+	kept := make([]Commit, 0, len(commits))
+	for i, commit := range commits {
+		if !dropped[i] {
+			kept = append(kept, commit)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+const (
+	imageCell = 12 // Pixels per side of a day
+	imageGap  = 3  // Pixels between days and around the grid
+)
+
+// Draw the matrix as an image with one square per day, laid out like the
+// terminal grid. The palette starts with a transparent entry that's used
+// for the gaps and for noDay slots when there's no PadColor, then has one
+// entry per level and one for the PadColor.
+func gridImage(matrix [][]int, opts RenderOptions) *image.Paletted {
+	palette := color.Palette{color.Transparent}
+	for level := range greens {
+		r, g, b := opts.levelColor(level).rgbValues()
+		palette = append(palette, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
+	}
+	padIndex := uint8(0)
+	if opts.PadColor != nil {
+		r, g, b := opts.PadColor.rgbValues()
+		palette = append(palette, color.RGBA{uint8(r), uint8(g), uint8(b), 255})
+		padIndex = uint8(len(palette) - 1)
+	}
+
+	step := imageCell + imageGap
+	img := image.NewPaletted(image.Rect(0, 0, len(matrix[0])*step+imageGap, len(matrix)*step+imageGap), palette)
+	for row := range matrix {
+		for col, count := range matrix[row] {
+			index := padIndex
+			if count != noDay {
+				index = uint8(opts.shownLevel(count) + 1)
+			}
+			for y := range imageCell {
+				for x := range imageCell {
+					img.SetColorIndex(imageGap+col*step+x, imageGap+row*step+y, index)
+				}
+			}
+		}
+	}
+	return img
+}
+
+// Write the window's calendar as a PNG image, to the --output file or to a
+// file in --output-dir named after the author and the window, see
+// outputDirPath. Returns the path written.
+func writePNG(cal Calendar) (string, error) {
+	path := *outputFlag
+	if path == "" {
+		var err error
+		path, err = outputDirPath(cal, ".png")
+		if err != nil {
+			return "", err
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	if err := png.Encode(file, gridImage(cal.Matrix, cal.Opts)); err != nil {
+		file.Close()
+		return "", err
+	}
+	return path, file.Close()
+}
+
+// File in --output-dir for a calendar with the given extension, e.g.
+// "jane-doe-2025.png". The directory is created if needed.
+func outputDirPath(cal Calendar, ext string) (string, error) {
+	if err := os.MkdirAll(*outputDirFlag, 0o755); err != nil {
+		return "", err
+	}
+	return filepath.Join(*outputDirFlag, fileLabel(cal.History)+"-"+cal.Window.Name+ext), nil
+}
+
+// The author in a form that's safe in file names, lower case with anything
+// but letters and digits replaced by dashes
+func fileLabel(history CommitHistory) string {
+	label := strings.Trim(unsafeFileChars.ReplaceAllString(strings.ToLower(history.Author), "-"), "-")
+	if label == "" {
+		return "gitcal"
+	}
+	return label
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^a-z0-9]+`)
@@ -0,0 +1,24 @@
+package main
+
+import "testing"
+
+func TestGridImageSize(t *testing.T) {
+	step := imageCell + imageGap
+	tests := []struct {
+		name        string
+		rows, weeks int
+	}{
+		{"day cells", rows, columns},
+		{"week strip", 1, columns},
+	}
+	for _, test := range tests {
+		matrix := make([][]int, test.rows)
+		for row := range matrix {
+			matrix[row] = make([]int, test.weeks)
+		}
+		bounds := gridImage(matrix, RenderOptions{}).Bounds()
+		if width, height := test.weeks*step+imageGap, test.rows*step+imageGap; bounds.Dx() != width || bounds.Dy() != height {
+			t.Errorf("%s: image is %dx%d, want %dx%d", test.name, bounds.Dx(), bounds.Dy(), width, height)
+		}
+	}
+}
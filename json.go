@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// A calendar as JSON for --format json and the API: the weeks of the grid,
+// each with its days in the window. Days outside the window are left out,
+// so the first and last weeks can be shorter than seven days.
+type calendarJSON struct {
+	From   string     `json:"from"`   // First day, YYYY-MM-DD
+	To     string     `json:"to"`     // Last day, YYYY-MM-DD
+	Metric string     `json:"metric"` // What count measures: commits or lines
+	Total  int        `json:"total"`  // Commits in the window
+	Weeks  []weekJSON `json:"weeks"`
+}
+
+type weekJSON struct {
+	Days []dayJSON `json:"days"`
+}
+
+type dayJSON struct {
+	Date    string `json:"date"`
+	Weekday int    `json:"weekday"` // 0 for Sunday, like time.Weekday
+	Count   int    `json:"count"`
+	Level   int    `json:"level"` // Intensity level as drawn, 0 to 4
+}
+
+// The window's calendar in its JSON form, scaled like the terminal grid
+func calendarData(cal Calendar) calendarJSON {
+	matrix, gridStart, opts := cal.Matrix, cal.GridStart, cal.Opts
+	calendar := calendarJSON{
+		From:   dayOf(cal.Window.Start).Format(time.DateOnly),
+		To:     dayOf(cal.Window.End).Format(time.DateOnly),
+		Metric: opts.Metric,
+		Total:  cal.Total,
+		Weeks:  make([]weekJSON, len(matrix[0])),
+	}
+	for col := range matrix[0] {
+		days := make([]dayJSON, 0, rows)
+		for row := range rows {
+			count := matrix[row][col]
+			if count == noDay {
+				continue
+			}
+			day := gridStart.AddDate(0, 0, col*rows+row)
+			days = append(days, dayJSON{
+				Date:    day.Format(time.DateOnly),
+				Weekday: int(day.Weekday()),
+				Count:   count,
+				Level:   opts.shownLevel(count),
+			})
+		}
+		calendar.Weeks[col] = weekJSON{Days: days}
+	}
+	return calendar
+}
+
+// Write the window's calendar as JSON to a file in --output-dir, see
+// outputDirPath. Returns the path written.
+func writeJSON(cal Calendar) (string, error) {
+	path, err := outputDirPath(cal, ".json")
+	if err != nil {
+		return "", err
+	}
+	out, err := json.MarshalIndent(calendarData(cal), "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(out, '\n'), 0o644)
+}
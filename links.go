@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// Matches GitHub remotes in both the ssh and https forms, e.g.
+// git@github.com:owner/repo.git and https://github.com/owner/repo
+var githubRemote = regexp.MustCompile(`^(?:git@github\.com:|(?:https|ssh)://(?:git@)?github\.com/)([^/]+)/(.+?)(?:\.git)?/?$`)
+
+// Link template for the origin remote when it's on GitHub, empty otherwise.
+// GitHub's commit list takes since and until dates, both inclusive.
+func githubLinkTemplate() string {
+	out, err := runGit("remote", "get-url", "origin")
+	if err != nil {
+		return ""
+	}
+	match := githubRemote.FindStringSubmatch(strings.TrimSpace(string(out)))
+	if match == nil {
+		return ""
+	}
+	return "https://github.com/" + match[1] + "/" + match[2] + "/commits?author={author}&since={date}&until={date}"
+}
+
+// Fill in a link template for a day. {date} is the day as YYYY-MM-DD,
+// {next} the day after (for exclusive ranges) and {author} the author.
+func dayLink(template string, day time.Time, author string) string {
+	return strings.NewReplacer(
+		"{date}", day.Format("2006-01-02"),
+		"{next}", day.AddDate(0, 0, 1).Format("2006-01-02"),
+		"{author}", url.QueryEscape(author),
+	).Replace(template)
+}
+
+// Wrap text in an OSC 8 hyperlink
+func hyperlink(link, text string) string {
+	return "\x1b]8;;" + link + "\x1b\\" + text + "\x1b]8;;\x1b\\"
+}
+
+// Best guess at whether the terminal renders OSC 8 hyperlinks. There's no
+// way to ask, so this goes by the variables the terminals known to support
+// them set. Terminals that don't support them usually ignore the codes, but
+// some print them, so anything unknown gets plain cells.
+func supportsHyperlinks() bool {
+	if color.NoColor {
+		return false // Not a terminal, or TERM=dumb
+	}
+	switch os.Getenv("TERM_PROGRAM") {
+	case "iTerm.app", "WezTerm", "vscode", "ghostty", "Hyper":
+		return true
+	}
+	if os.Getenv("WT_SESSION") != "" || os.Getenv("KONSOLE_VERSION") != "" {
+		return true
+	}
+	// GNOME Terminal and other VTE based ones since 0.50
+	if vte, err := strconv.Atoi(os.Getenv("VTE_VERSION")); err == nil && vte >= 5000 {
+		return true
+	}
+	term := os.Getenv("TERM")
+	return strings.Contains(term, "kitty") || strings.Contains(term, "foot") || strings.Contains(term, "alacritty")
+}
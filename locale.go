@@ -0,0 +1,95 @@
+package main
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Month and weekday abbreviations for a --locale, weekdays start on Sunday
+// like time.Weekday
+type localeNames struct {
+	months   [12]string
+	weekdays [7]string
+}
+
+// Languages for --locale, in each language's own capitalization
+var locales = map[string]localeNames{
+	"en": {
+		[12]string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		[7]string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		[12]string{"Jan", "Feb", "Mär", "Apr", "Mai", "Jun", "Jul", "Aug", "Sep", "Okt", "Nov", "Dez"},
+		[7]string{"So", "Mo", "Di", "Mi", "Do", "Fr", "Sa"},
+	},
+	"es": {
+		[12]string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sep", "oct", "nov", "dic"},
+		[7]string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"fr": {
+		[12]string{"jan", "fév", "mar", "avr", "mai", "jun", "jul", "aoû", "sep", "oct", "nov", "déc"},
+		[7]string{"dim", "lun", "mar", "mer", "jeu", "ven", "sam"},
+	},
+	"it": {
+		[12]string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		[7]string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		[12]string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		[7]string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pl": {
+		[12]string{"sty", "lut", "mar", "kwi", "maj", "cze", "lip", "sie", "wrz", "paź", "lis", "gru"},
+		[7]string{"nie", "pon", "wto", "śro", "czw", "pią", "sob"},
+	},
+	"pt": {
+		[12]string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		[7]string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+	"sv": {
+		[12]string{"jan", "feb", "mar", "apr", "maj", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		[7]string{"sön", "mån", "tis", "ons", "tor", "fre", "lör"},
+	},
+}
+
+// The supported locales, sorted, for error messages
+func localeList() string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Cut or pad a name to exactly three characters, which is what the month
+// header and the chart leave room for
+func fitName(name string) string {
+	runes := []rune(name)
+	if len(runes) > 3 {
+		runes = runes[:3]
+	}
+	return string(runes) + strings.Repeat(" ", 3-len(runes))
+}
+
+// Abbreviated month name in the chosen locale
+func (opts RenderOptions) monthName(m time.Month) string {
+	return fitName(locales[opts.Locale].months[m-1])
+}
+
+// Abbreviated weekday name in the chosen locale
+func (opts RenderOptions) weekdayName(d time.Weekday) string {
+	return fitName(locales[opts.Locale].weekdays[d])
+}
+
+// A day as e.g. "Mon Jan 2, 2006" in the chosen locale
+func (opts RenderOptions) dayName(day time.Time) string {
+	weekday := strings.TrimSpace(opts.weekdayName(day.Weekday()))
+	return weekday + " " + strings.TrimSpace(opts.monthName(day.Month())) + " " + day.Format("2, 2006")
+}
+
+// A month as e.g. "Jan 2006" in the chosen locale
+func monthYear(t time.Time, opts RenderOptions) string {
+	return strings.TrimSpace(opts.monthName(t.Month())) + " " + t.Format("2006")
+}
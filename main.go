@@ -1,13 +1,20 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
 	"github.com/fatih/color"
 	"gopkg.in/yaml.v3"
 )
@@ -26,57 +33,147 @@ var style = lipgloss.NewStyle().
 	Background(lipgloss.Color("#30383aff")).
 	Border(lipgloss.RoundedBorder()).
 	BorderForeground(lipgloss.Color("#ffffff")).
-	BorderStyle(lipgloss.NormalBorder()).
-	PaddingTop(1).
-	PaddingLeft(1).
-	PaddingRight(2).
-	PaddingBottom(0)
+	BorderStyle(lipgloss.NormalBorder())
+
+// Space between the grid and the box as top, right, bottom and left, in
+// lines and columns. Cells are two columns wide, so two columns look as
+// wide as a line is high.
+type boxPadding [4]int
+
+var defaultPadding = boxPadding{1, 2, 1, 2}
+
+// The config takes a single value for every side or a list of four
+func (p *boxPadding) UnmarshalYAML(node *yaml.Node) error {
+	var all int
+	if err := node.Decode(&all); err == nil {
+		*p = boxPadding{all, all, all, all}
+		return nil
+	}
+	var sides []int
+	if err := node.Decode(&sides); err != nil || len(sides) != 4 {
+		return fmt.Errorf("padding must be a number or a list of four like [1, 2, 1, 2]")
+	}
+	copy(p[:], sides)
+	return nil
+}
+
+// The box around the grid with the configured padding
+func (opts RenderOptions) box() lipgloss.Style {
+	p := opts.Padding
+	if opts.ASCII {
+		// Spaces take the border's place so everything still lines up
+		return style.Border(lipgloss.HiddenBorder()).Padding(p[0], p[1], p[2], p[3])
+	}
+	return style.Padding(p[0], p[1], p[2], p[3])
+}
 
 type Commit struct {
-	Hash      string
-	Author    string
-	Timestamp time.Time
+	Hash           string
+	Author         string
+	AuthorEmail    string
+	Committer      string
+	CommitterEmail string
+	Subject        string
+	Coauthors      []string // "Name <email>" from Co-authored-by trailers
+	Parents        int      // More than one for merges
+	FilesChanged   int      // Only known when fetched with --numstat
+	Additions      int      // Only known when fetched with --numstat
+	Deletions      int      // Only known when fetched with --numstat
+	BinaryFiles    int      // Changed files without line counts, only known with --numstat
+	Weight         float64  // How much the commit counts, see weighMerges
+	Timestamp      time.Time
+}
+
+// Weights of a merge commit for --count-merges-as, other commits count fully
+var mergeWeights = map[string]float64{"full": 1, "half": 0.5, "zero": 0}
+
+// Set every commit's weight, merges get mergeWeight
+func weighMerges(commits []Commit, mergeWeight float64) {
+	for i := range commits {
+		commits[i].Weight = 1
+		if commits[i].Parents > 1 {
+			commits[i].Weight = mergeWeight
+		}
+	}
 }
 
 type CommitHistory struct {
 	Author  string
+	Filter  LogFilter
 	Commits []Commit
+	Tags    []Tag // Releases by the author, nil unless read with --show-tags
 }
 
 type Config struct {
-	Author string `yaml:"author"`
-}
-
-// Enums to strings shorthand
-func MonthString(m time.Month) string {
-	switch m {
-	case time.January:
-		return "Jan"
-	case time.February:
-		return "Feb"
-	case time.March:
-		return "Mar"
-	case time.April:
-		return "Apr"
-	case time.May:
-		return "May"
-	case time.June:
-		return "Jun"
-	case time.July:
-		return "Jul"
-	case time.August:
-		return "Aug"
-	case time.September:
-		return "Sep"
-	case time.October:
-		return "Oct"
-	case time.November:
-		return "Nov"
-	case time.December:
-		return "Dec"
-	default:
-		return ""
+	Author        string      `yaml:"author"`
+	PadColor      string      `yaml:"pad_color"`      // Background for slots outside the window, hex or named
+	EmptyColor    string      `yaml:"empty_color"`    // Background for days without contributions, hex or named
+	LegendLabels  []string    `yaml:"legend_labels"`  // Low and high end of the legend, e.g. [Quiet, Busy]
+	ShowMonths    *bool       `yaml:"show_months"`    // Print the month header, defaults to true
+	LinkURL       string      `yaml:"link_url"`       // Where --links points each day, see dayLink
+	StatsFields   []string    `yaml:"stats_fields"`   // Lines of the --stats block
+	Padding       *boxPadding `yaml:"padding"`        // Space around the grid, nil for defaultPadding
+	Theme         string      `yaml:"theme"`          // Default for --theme
+	ExcludeRanges []string    `yaml:"exclude_ranges"` // Days left out of streaks and active days, see parseExcludeRanges
+	Profiles      yaml.Node   `yaml:"profiles"`       // Named sets of the settings above, see applyProfile
+}
+
+// GitHub's color for days without contributions
+const defaultEmptyColor = "#161b22"
+
+// Filters passed through to git log. Author and committer are independent:
+// when both are set git only returns commits matching both.
+// ByMe instead counts a commit when Author matches either the author or the
+// committer, which git can't express, so it's applied after parsing.
+type LogFilter struct {
+	Author         string
+	Committer      string
+	ByMe           bool
+	ExcludeEmpty   bool // Drop commits that don't change any files
+	ExcludeReverts bool // Drop reverts together with the commit they revert
+	Coauthors      bool // Also count commits listing Author in a Co-authored-by trailer
+	Lines          bool // Fetch added and deleted line counts
+	Branch         string
+	NoMerges       bool
+	FirstParent    bool
+}
+
+// Human readable description of the active filters, used in the totals line
+func (f LogFilter) String() string {
+	parts := make([]string, 0, 4)
+	if f.ByMe && f.Coauthors {
+		parts = append(parts, "author, committer or co-author "+f.Author)
+	} else if f.ByMe {
+		parts = append(parts, "author or committer "+f.Author)
+	} else if f.Coauthors {
+		parts = append(parts, "author or co-author "+f.Author)
+		if f.Committer != "" {
+			parts = append(parts, "committer "+f.Committer)
+		}
+	} else {
+		if f.Author != "" {
+			parts = append(parts, "author "+f.Author)
+		}
+		if f.Committer != "" {
+			parts = append(parts, "committer "+f.Committer)
+		}
+	}
+	if f.Branch != "" {
+		parts = append(parts, "on "+f.Branch)
 	}
+	if f.FirstParent {
+		parts = append(parts, "first parent only")
+	}
+	if f.NoMerges {
+		parts = append(parts, "without merges")
+	}
+	if f.ExcludeEmpty {
+		parts = append(parts, "without empty commits")
+	}
+	if f.ExcludeReverts {
+		parts = append(parts, "without reverts")
+	}
+	return strings.Join(parts, ", ")
 }
 
 // Simulate a GitHub-like calendar with 7 rows and 52 columns
@@ -85,124 +182,1439 @@ const (
 	columns = 52 // Weeks of the year
 )
 
-func printCommitHistory(history CommitHistory, uptoDate time.Time) {
-	// Go back a 7 * 52 = 364 days from the current date
-	startDate := uptoDate.AddDate(0, 0, -rows*columns+1)
+// First day of each week, the top row of the grid
+const weekStart = time.Sunday
+
+// Matrix cell for a slot that isn't a day in the window, e.g. the days of
+// the first week before the window starts or the days after today
+const noDay = -1
+
+// Settings that only affect how the calendar is drawn, not what's counted
+type RenderOptions struct {
+	MinLevel   int                // Levels below this are drawn as empty cells
+	Legend     bool               // Print a Less/More legend below the calendar
+	Style      string             // How cells are drawn: background, block or hybrid
+	PadColor   *paletteColor      // Color for noDay cells, nil leaves them blank
+	EmptyColor *paletteColor      // Color for level 0, nil falls back to greens[0]
+	LowLabel   string             // Legend text before the lowest level
+	HighLabel  string             // Legend text after the highest level
+	NoMonths   bool               // Leave out the month header above the grid
+	MaxCount   int                // Commits that reach the top level, 0 for one level per commit
+	LinkURL    string             // Template each day links to with OSC 8, see dayLink
+	LinkAuthor string             // Fills {author} in LinkURL
+	Sixel      bool               // Draw the grid as a Sixel image instead of cells
+	Metric     string             // What a day's intensity measures: commits or lines
+	TagDays    map[time.Time]bool // Days marked as releases, keyed by dayOf
+	Locale     string             // Language of month and weekday names, see locales
+	Padding    boxPadding         // Space around the grid inside the box
+	Theme      []paletteColor     // Colors for levels above 0, nil uses greens, see themes
+	FooterDate bool               // Describe the focused day on a line below the calendar
+	Weekly     bool               // One cell per week in a single row, see weekSums
+	Excluded   map[time.Time]bool // Days dimmed for being excluded from the stats, keyed by dayOf
+	ASCII      bool               // Only write ASCII, for terminals that can't show UTF-8
+}
+
+// Metrics for --metric
+const (
+	metricCommits = "commits" // Number of commits
+	metricLines   = "lines"   // Lines added plus deleted
+)
+
+// Intensity level for a day's commit count. Counts are spread linearly over
+// the levels with MaxCount or more commits at the top level. Without a
+// MaxCount every commit adds a level, which is the same as MaxCount being
+// the number of levels above 0.
+func (opts RenderOptions) level(count int) int {
+	top := len(greens) - 1
+	maxCount := opts.scaleMax()
+	// Round up so any commit at all lifts a day above level 0
+	level := (count*top + maxCount - 1) / maxCount
+	return min(level, top)
+}
+
+// Value that reaches the top level
+func (opts RenderOptions) scaleMax() int {
+	if opts.MaxCount > 0 {
+		return opts.MaxCount
+	}
+	return len(greens) - 1
+}
+
+// Values a level stands for, e.g. "51–100", the inverse of level. The top
+// level is open ended and levels no value maps to, which happens when the
+// scale is shorter than the levels, are empty.
+func (opts RenderOptions) levelRange(level int) string {
+	if level == 0 {
+		return "0"
+	}
+	top, maxCount := len(greens)-1, opts.scaleMax()
+	low, high := (level-1)*maxCount/top+1, level*maxCount/top
+	switch {
+	case level == top:
+		return fmt.Sprintf("%d+", low)
+	case low > high:
+		return ""
+	case low == high:
+		return strconv.Itoa(low)
+	default:
+		return fmt.Sprintf("%d–%d", low, high)
+	}
+}
+
+// Scale to the busiest cell of the commits when there's no fixed scale and
+// the cells need one. One level per commit is a fine default for a day,
+// not for a line or a week.
+func (opts RenderOptions) scaledTo(commits []Commit) RenderOptions {
+	if opts.MaxCount == 0 && (opts.Metric == metricLines || opts.Weekly) {
+		opts.MaxCount = opts.busiest(commits)
+	}
+	return opts
+}
+
+// Largest value a cell gets from the commits, a day's or a week's
+func (opts RenderOptions) busiest(commits []Commit) int {
+	if opts.Weekly {
+		return busiestWeek(commits, opts.Metric)
+	}
+	return busiestValue(commits, opts.Metric)
+}
+
+// What a cell covers, for labels like "commits a day"
+func (opts RenderOptions) period() string {
+	if opts.Weekly {
+		return "week"
+	}
+	return "day"
+}
+
+// Color for a capped level, level 0 uses the configurable empty color
+// rather than the intensity palette
+func (opts RenderOptions) levelColor(level int) paletteColor {
+	if level == 0 && opts.EmptyColor != nil {
+		return *opts.EmptyColor
+	}
+	if level > 0 && opts.Theme != nil {
+		return opts.Theme[(level-1)%len(opts.Theme)]
+	}
+	return paletteColor{attr: greens[level%len(greens)]}
+}
+
+// Cell styles for --style
+const (
+	styleBackground = "background" // Colored background behind blank cells
+	styleBlock      = "block"      // Colored full blocks on the terminal's background
+	styleHybrid     = "hybrid"     // Colored background with a density glyph on top
+)
+
+// Density glyph for each level in the hybrid style, doubled to fill a cell
+var levelGlyphs = []rune(" ░▒▓█")
 
-	// Get all commits in the last year
-	commits := make([]Commit, 0)
-	for _, commit := range history.Commits {
-		if commit.Timestamp.After(startDate) && commit.Timestamp.Before(uptoDate) {
-			commits = append(commits, commit)
+// Draw a two column cell in the given color at the given level. Focused
+// cells show a marker instead of their usual content.
+func (opts RenderOptions) drawCell(c paletteColor, level int, focused bool) string {
+	if opts.ASCII {
+		// Backgrounds would be fine, but the glyphs carry the level in
+		// every style so the grid stays readable without colors too
+		if focused {
+			return c.fg().Sprint("[]")
 		}
+		return c.fg().Sprint(strings.Repeat(string(asciiGlyphs[level]), 2))
 	}
+	switch opts.Style {
+	case styleBlock:
+		if focused {
+			return c.fg().Sprint("[]")
+		}
+		return c.fg().Sprint("██")
+	case styleHybrid:
+		// Dark glyphs stay visible on the light top levels
+		tinted := c.bg().Add(color.FgBlack)
+		if focused {
+			return tinted.Sprint("[]")
+		}
+		return tinted.Sprint(strings.Repeat(string(levelGlyphs[level]), 2))
+	default:
+		if focused {
+			return c.bg().Sprint("[]")
+		}
+		return c.bg().Sprint("  ") // Two spaces for each cell
+	}
+}
 
-	// Print the header with the rough month names
-	fmt.Print(" ")
-	for week := range columns {
-		// Calculate the month for this week
-		month := startDate.AddDate(0, 0, week*7).Month()
-		// Print the month name
-		if week%4 == 0 { // Print month name every 4 weeks
-			fmt.Printf("%s ", MonthString(month))
-		} else {
-			fmt.Print("   ") // Print spaces for other weeks
+// Truncate a timestamp to its calendar day so days can be compared and used
+// as map keys regardless of time of day or zone
+func dayOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Commits whose day falls within [startDate, uptoDate]
+func commitsInWindow(commits []Commit, startDate, uptoDate time.Time) []Commit {
+	first, last := dayOf(startDate), dayOf(uptoDate)
+	inWindow := make([]Commit, 0)
+	for _, commit := range commits {
+		day := dayOf(commit.Timestamp)
+		if !day.Before(first) && !day.After(last) {
+			inWindow = append(inWindow, commit)
 		}
 	}
-	fmt.Println()
-	output := ""
+	return inWindow
+}
+
+// First day of the week containing day
+func weekOf(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday()-weekStart)+rows)%rows)
+}
+
+// Number of columns a grid for [startDate, uptoDate] has
+func gridWeeks(startDate, uptoDate time.Time) int {
+	return int(dayOf(uptoDate).Sub(weekOf(dayOf(startDate))).Hours()/24)/rows + 1
+}
+
+// Count commits per day into a rows x weeks matrix. Each column is a week
+// starting on weekStart and each row a weekday, so the first and last columns
+// can contain slots outside the window, those are set to noDay. So are the
+// placeholder days up to the window's GridEnd. Returns the matrix together
+// with the date of its top left slot.
+func buildMatrix(commits []Commit, window Window, metric string) ([][]int, time.Time) {
+	first, last := dayOf(window.Start), dayOf(window.End)
+	gridStart := weekOf(first)
+	weeks := gridWeeks(window.Start, window.End)
+	if window.GridEnd.After(window.End) {
+		weeks = gridWeeks(window.Start, window.GridEnd)
+	}
+
+	counts := dailyValues(commits, metric)
+
+	matrix := make([][]int, rows)
 	for row := range rows {
-		for col := 0; col < columns; col++ {
-			// Calculate the date for this cell
-			date := startDate.AddDate(0, 0, row*columns+col)
-			level := 0 // Default level for no contributions
-			// Count contributions for this date
-			for _, commit := range commits {
-
-				if commit.Timestamp.Year() == date.Year() && commit.Timestamp.YearDay() == date.YearDay() {
-					level++ // Increment level for each contribution on this date
-				}
+		matrix[row] = make([]int, weeks)
+		for col := range weeks {
+			date := gridStart.AddDate(0, 0, col*rows+row)
+			if date.Before(first) || date.After(last) {
+				matrix[row][col] = noDay
+				continue
 			}
-			// Print the level in the calendar data
-			if level >= len(greens) {
-				level = len(greens) - 1 // Cap the level to the maximum defined
+			matrix[row][col] = counts[date]
+		}
+	}
+	return matrix, gridStart
+}
+
+// Collapse a matrix into a single row holding each column's total, for
+// --granularity week. Columns without any day of the window stay noDay.
+func weekSums(matrix [][]int) [][]int {
+	sums := make([]int, len(matrix[0]))
+	for col := range sums {
+		sums[col] = noDay
+		for row := range matrix {
+			if count := matrix[row][col]; count != noDay {
+				sums[col] = max(sums[col], 0) + count
 			}
-			c := color.New(greens[level%len(greens)])
-			output += " " + c.Sprint("  ") // Two spaces for each cell
 		}
-		output += "\n" // New line after each row
 	}
-	styledOutput := style.Render(output)
-	fmt.Print(styledOutput)
+	return [][]int{sums}
+}
+
+// Go back a 7 * 52 = 364 days from the given date
+func windowStart(uptoDate time.Time) time.Time {
+	return uptoDate.AddDate(0, 0, -rows*columns+1)
+}
+
+// Widest grid drawn for a single window, a year can start mid week so it
+// needs one column more than the 52 full weeks
+const maxWeeks = columns + 1
+
+// Range of days drawn as one calendar
+type Window struct {
+	Start, End time.Time
+	Label      string    // Completes "N contributions ...", e.g. "in the last year"
+	Name       string    // Identifies the window in file names, e.g. "2025"
+	GridEnd    time.Time // Draw placeholders after End up to this day, zero for none
+	Year       bool      // A calendar year, --diff compares it with the year before
+}
+
+// The default window, the 364 days up to uptoDate
+func lastYearWindow(uptoDate time.Time) Window {
+	return Window{Start: windowStart(uptoDate), End: uptoDate, Label: "in the last year", Name: "last-year"}
+}
+
+// One window per calendar year from first's year to uptoDate's, the current
+// year ends at uptoDate
+func yearWindows(first, uptoDate time.Time) []Window {
+	windows := make([]Window, 0)
+	for year := first.Year(); year <= uptoDate.Year(); year++ {
+		window := Window{
+			Start: time.Date(year, time.January, 1, 0, 0, 0, 0, uptoDate.Location()),
+			End:   time.Date(year, time.December, 31, 0, 0, 0, 0, uptoDate.Location()),
+			Label: fmt.Sprintf("in %d", year),
+			Name:  strconv.Itoa(year),
+			Year:  true,
+		}
+		if year == uptoDate.Year() {
+			// The rest of the year is drawn as placeholders, keeping every
+			// year's grid the same size
+			window.End, window.GridEnd = uptoDate, window.End
+		}
+		windows = append(windows, window)
+	}
+	return windows
+}
+
+// Earliest commit, commits must not be empty
+func firstCommit(commits []Commit) time.Time {
+	first := commits[0].Timestamp
+	for _, commit := range commits {
+		if commit.Timestamp.Before(first) {
+			first = commit.Timestamp
+		}
+	}
+	return first
+}
+
+// A single window from the first commit up to uptoDate when it fits in
+// maxWeeks, otherwise one per calendar year
+func sinceFirstCommitWindows(commits []Commit, uptoDate time.Time) []Window {
+	first := firstCommit(commits)
+	if gridWeeks(first, uptoDate) > maxWeeks {
+		return yearWindows(first, uptoDate)
+	}
+	return []Window{{Start: first, End: uptoDate, Label: "since " + first.Format("Jan 2, 2006"), Name: "since-" + first.Format("2006-01-02")}}
+}
+
+// Month names above a grid starting at gridStart, each over the first week
+// that starts in its month
+func monthHeader(gridStart time.Time, weeks int, opts RenderOptions) string {
+	// Start where the grid does, inside the box's border and left padding
+	offset := 1 + opts.Padding[3]
+	header := []rune(strings.Repeat(" ", offset+weeks*3))
+	end := 0 // Column after the last name written
+	for week := range weeks {
+		// Name the first week and each one starting in a new month
+		start := gridStart.AddDate(0, 0, week*rows)
+		if week > 0 && start.AddDate(0, 0, -rows).Month() == start.Month() {
+			continue
+		}
+		// Every week is a cell and a space wide, three columns
+		column := offset + week*3
+		if column <= end {
+			continue // Too close to the previous name, leave a space
+		}
+		end = column + copy(header[column:], []rune(opts.monthName(start.Month())))
+	}
+	return strings.TrimRight(string(header), " ")
+}
+
+// A day excluded from the stats, shaded in the color of its level so the
+// commits still show but the day stands out as a break
+func (opts RenderOptions) excludedCell(count int) string {
+	if opts.ASCII {
+		return opts.levelColor(opts.shownLevel(count)).fg().Sprint("~~")
+	}
+	return opts.levelColor(opts.shownLevel(count)).fg().Sprint("░░")
+}
+
+// Marker for a release day, drawn over the day's cell so its level still
+// shows in the background
+func (opts RenderOptions) tagCell(count int) string {
+	marker := color.New(color.FgHiMagenta)
+	if opts.Style != styleBlock && !opts.ASCII {
+		marker = opts.levelColor(opts.shownLevel(count)).bg().Add(color.FgHiMagenta)
+	}
+	if opts.ASCII {
+		return marker.Sprint("^ ")
+	}
+	return marker.Sprint("◆ ")
+}
+
+// Draw the cell for a matrix value
+func (opts RenderOptions) cell(count int, focused bool) string {
+	if count == noDay {
+		// Not a day in the window, keep it distinct from a quiet day
+		if opts.PadColor == nil || opts.ASCII {
+			return "  "
+		}
+		return opts.drawCell(*opts.PadColor, 0, false)
+	}
+	level := opts.shownLevel(count)
+	return opts.drawCell(opts.levelColor(level), level, focused)
+}
+
+// Level a day is drawn at, after hiding the ones below MinLevel
+func (opts RenderOptions) shownLevel(count int) int {
+	level := opts.level(count)
+	if level < opts.MinLevel {
+		level = 0 // Hide quieter days, the totals still count them
+	}
+	return level
+}
+
+// Draw the matrix as rows of cells. The cell at focusRow, focusCol gets a
+// marker, pass -1 to draw without one. gridStart is only used for links.
+func renderGrid(matrix [][]int, gridStart time.Time, opts RenderOptions, focusRow, focusCol int) string {
+	output := ""
+	for row := range matrix {
+		for col, count := range matrix[row] {
+			focused := row == focusRow && col == focusCol
+			cell := opts.cell(count, focused)
+			day := gridStart.AddDate(0, 0, col*rows+row)
+			if opts.Excluded[day] && count != noDay && !focused {
+				cell = opts.excludedCell(count)
+			}
+			if opts.TagDays[day] && count != noDay && !focused {
+				cell = opts.tagCell(count)
+			}
+			if opts.LinkURL != "" && count != noDay {
+				cell = hyperlink(dayLink(opts.LinkURL, day, opts.LinkAuthor), cell)
+			}
+			// Cells are spaced apart, the padding does the rest
+			if col > 0 {
+				output += " "
+			}
+			output += cell
+		}
+		if row < len(matrix)-1 {
+			output += "\n" // New line after each row
+		}
+	}
+	return output
+}
+
+// The calendar, legend and totals of a window, ending in a newline
+func calendarText(cal Calendar) string {
+	opts, history, window := cal.Opts, cal.History, cal.Window
+	weeks := len(cal.Matrix[0])
+
+	text := ""
+	if opts.Sixel {
+		// Text month names wouldn't line up with the pixels
+		text += encodeSixel(gridImage(cal.Matrix, opts)) + "\n"
+	} else {
+		if !opts.NoMonths {
+			text += monthHeader(cal.GridStart, weeks, opts) + "\n"
+		}
+		output := renderGrid(cal.Matrix, cal.GridStart, opts, -1, -1)
+		text += opts.box().Render(output) + "\n"
+	}
+	if opts.Legend {
+		text += legendText(opts) + "\n"
+	}
+	// Totals only count commits matched by the filters given to git log
+	if history.Tags != nil {
+		releases := len(tagsInWindow(history.Tags, window.Start, window.End))
+		text += fmt.Sprintf("%d contributions and %s %s (%s)\n", cal.Total, plural(releases, "release"), window.Label, history.Filter)
+	} else {
+		text += fmt.Sprintf("%d contributions %s (%s)\n", cal.Total, window.Label, history.Filter)
+	}
+	return opts.encode(text)
+}
+
+// A GitHub style "Less ... More" legend with one cell per level, each
+// followed by the values it stands for in the metric's unit
+func legendText(opts RenderOptions) string {
+	legend := opts.LowLabel
+	for level := range greens {
+		values := opts.levelRange(level)
+		if values == "" {
+			continue // Nothing is drawn at this level
+		}
+		shown := level
+		if shown < opts.MinLevel {
+			shown = 0 // Match how hidden levels look in the calendar
+		}
+		legend += " " + opts.drawCell(opts.levelColor(shown), shown, false) + " " + values
+	}
+	legend += " " + opts.HighLabel + " (" + opts.Metric + " a " + opts.period() + ")"
+	if opts.TagDays != nil {
+		legend += "  " + opts.tagCell(0) + "release"
+	}
+	if len(opts.Excluded) > 0 {
+		legend += "  " + opts.excludedCell(0) + " excluded"
+	}
+	if opts.MinLevel > 1 {
+		legend += fmt.Sprintf(" (levels below %d hidden)", opts.MinLevel)
+	}
+	return legend
+}
+
+// Match the identity the same way git does for --author/--committer,
+// against "Name <email>"
+func (c Commit) touchedBy(identity *regexp.Regexp) bool {
+	return identity.MatchString(c.Author+" <"+c.AuthorEmail+">") ||
+		identity.MatchString(c.Committer+" <"+c.CommitterEmail+">")
+}
+
+// Whether the identity is one of the commit's co-authors
+func (c Commit) coauthoredBy(identity *regexp.Regexp) bool {
+	for _, coauthor := range c.Coauthors {
+		if identity.MatchString(coauthor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply the filters git can't, ByMe and Coauthors, with the identity
+// compiled from the filter's author
+func (c Commit) matches(identity *regexp.Regexp, filter LogFilter) bool {
+	if filter.Coauthors && c.coauthoredBy(identity) {
+		return true
+	}
+	if filter.ByMe {
+		return c.touchedBy(identity)
+	}
+	return identity.MatchString(c.Author + " <" + c.AuthorEmail + ">")
+}
+
+// Fields of a commit's header line in git log's output, the format is
+// built from these so a field can only be added together with its index.
+// They're tab separated since names can contain spaces, and the subject
+// goes last so tabs in it can't shift the other fields.
+var logFields = []string{
+	"%h",  // Abbreviated hash
+	"%aI", // Author date, strict ISO 8601 whatever log.date says
+	"%an", "%ae",
+	"%cn", "%ce",
+	"%P", // Parent hashes, space separated
+	"%(trailers:key=Co-authored-by,valueonly,separator=%x1f)",
+	"%s",
+}
+
+const (
+	fieldHash = iota
+	fieldDate
+	fieldAuthor
+	fieldAuthorEmail
+	fieldCommitter
+	fieldCommitterEmail
+	fieldParents
+	fieldCoauthors
+	fieldSubject
+)
+
+// git log's --pretty format for logFields. Each commit starts with a record
+// separator so --numstat lines can follow its header.
+var logFormat = "%x1e" + strings.Join(logFields, "%x09")
+
+// Added or deleted count of a --numstat line
+var numstatCount = regexp.MustCompile(`^(\d+|-)$`)
+
+// Runs git in the current directory and returns what it printed, with
+// git's own message in the error when it fails. A variable so it can be
+// swapped for one that doesn't need a repository.
+var gitRunner = func(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = "." // Set the working directory to the current directory
+	out, err := cmd.Output()
+	if err != nil {
+		// Include what git said, e.g. "not a git repository"
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+		}
+	}
+	return out, err
+}
+
+// How often a transient git failure is retried, waiting gitRetryDelay
+// before the first retry and twice as long before each one after that
+const (
+	gitRetries    = 2
+	gitRetryDelay = 200 * time.Millisecond
+)
+
+// What git says when it fails because of another git process, e.g. one
+// holding index.lock, rather than because of the repository or the command
+var transientGitErrors = []string{
+	".lock': File exists",
+	"Another git process seems to be running",
+	"cannot lock ref",
+	"could not lock config file",
+}
+
+func transientGitError(err error) bool {
+	for _, message := range transientGitErrors {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// Run git, retrying when it fails for one of the transientGitErrors. Any
+// other failure, like not being in a repository, is returned right away.
+func runGit(args ...string) ([]byte, error) {
+	for attempt := 0; ; attempt++ {
+		out, err := gitRunner(args...)
+		if err == nil || attempt == gitRetries || !transientGitError(err) {
+			return out, err
+		}
+		time.Sleep(gitRetryDelay << attempt)
+	}
 }
 
 // run git log and parse into a format similar to GitHub's contribution graph
-func runGitLog(author string) (CommitHistory, error) {
+func runGitLog(filter LogFilter) (CommitHistory, error) {
 	// use os/exec to run git log and parse the output
-	cmd := exec.Command("git", "log", "--author="+author, "--pretty=format:%h %ad", "--date=short")
-	cmd.Dir = "." // Set the working directory to the current directory
-	outputbytes, err := cmd.Output()
+	args := []string{"log", "--pretty=format:" + logFormat}
+	// Whatever the user's config says, only the format above may show up:
+	// log.showSignature would print gpg output between the commits
+	args = append(args, "--no-show-signature", "--no-color")
+	if filter.ExcludeEmpty || filter.Lines {
+		args = append(args, "--numstat")
+	}
+	var identity *regexp.Regexp
+	if filter.ByMe || filter.Coauthors {
+		// git can't match co-authors and ANDs --author and --committer, so
+		// fetch everything and match here
+		re, err := regexp.Compile(filter.Author)
+		if err != nil {
+			return CommitHistory{}, fmt.Errorf("invalid author pattern %q: %v", filter.Author, err)
+		}
+		identity = re
+	} else if filter.Author != "" {
+		args = append(args, "--author="+filter.Author)
+	}
+	if filter.Committer != "" && !filter.ByMe {
+		args = append(args, "--committer="+filter.Committer)
+	}
+	if filter.NoMerges {
+		args = append(args, "--no-merges")
+	}
+	if filter.FirstParent {
+		args = append(args, "--first-parent")
+	}
+	if filter.Branch != "" {
+		// Check the ref first, git log's own error for a bad revision is vague
+		if _, err := runGit("rev-parse", "--verify", "--quiet", filter.Branch+"^{commit}"); err != nil {
+			// With --quiet a missing ref only exits with 1, anything else
+			// is git failing, e.g. outside of a repository
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+				return CommitHistory{}, fmt.Errorf("branch %q doesn't exist", filter.Branch)
+			}
+			return CommitHistory{}, fmt.Errorf("checking branch %q: %w", filter.Branch, err)
+		}
+		// The trailing -- stops git from reading the ref as a path
+		args = append(args, filter.Branch, "--")
+	}
+	outputbytes, err := runGit(args...)
 	if err != nil {
-		fmt.Printf("Failed to get output: %v", err)
 		return CommitHistory{}, err
 	}
-	// Split the output into lines
+	// Split the output into one record per commit
 	output := string(outputbytes)
 	if len(output) == 0 {
-		return CommitHistory{}, fmt.Errorf("no contributions found")
+		return CommitHistory{}, errNoCommits
 	}
 
-	lines := strings.Split(output, "\n")
-	commits := make([]Commit, 0, len(lines))
-	for _, line := range lines {
+	// Anything before the first separator isn't a commit
+	records := strings.Split(output, "\x1e")[1:]
+	commits := make([]Commit, 0, len(records))
+	parsed := 0 // Records that were commits, whether they matched or not
+	for _, record := range records {
+		// Only newlines, an empty subject leaves a trailing tab that must stay
+		lines := strings.Split(strings.Trim(record, "\n"), "\n")
 
-		parts := strings.SplitN(line, " ", 2)
-		if len(parts) < 2 {
+		parts := strings.SplitN(lines[0], "\t", len(logFields))
+		if len(parts) != len(logFields) {
 			continue // Skip lines that don't have enough parts
 		}
-		hash := parts[0]
-		dateStr := parts[1]
-		date, err := time.Parse("2006-01-02", dateStr)
+		// Keep the author's own zone so commits land on their local day
+		date, err := time.Parse(time.RFC3339, parts[fieldDate])
 		if err != nil {
-			fmt.Printf("Failed to parse date %s: %v\n", dateStr, err)
+			// On stderr, stdout may be JSON or a prompt
+			fmt.Fprintf(os.Stderr, "Failed to parse date %s: %v\n", parts[fieldDate], err)
 			continue // Skip lines with invalid dates
 		}
-		commits = append(commits, Commit{
-			Hash:      hash,
-			Author:    author,
-			Timestamp: date,
-		})
+		parsed++
+		commit := Commit{
+			Hash:           parts[fieldHash],
+			Author:         parts[fieldAuthor],
+			AuthorEmail:    parts[fieldAuthorEmail],
+			Committer:      parts[fieldCommitter],
+			CommitterEmail: parts[fieldCommitterEmail],
+			Parents:        len(strings.Fields(parts[fieldParents])),
+			Subject:        parts[fieldSubject],
+			Timestamp:      date,
+		}
+		for _, coauthor := range strings.Split(parts[fieldCoauthors], "\x1f") {
+			if coauthor = strings.TrimSpace(coauthor); coauthor != "" {
+				commit.Coauthors = append(commit.Coauthors, coauthor)
+			}
+		}
+		// The remaining lines are --numstat entries, one per changed file as
+		// "added<TAB>deleted<TAB>path", binary files show "-" for both.
+		// Anything else, e.g. signature output git printed anyway, is skipped.
+		for _, line := range lines[1:] {
+			fields := strings.SplitN(line, "\t", 3)
+			if len(fields) < 3 || !numstatCount.MatchString(fields[0]) || !numstatCount.MatchString(fields[1]) {
+				continue
+			}
+			commit.FilesChanged++
+			if fields[0] == "-" {
+				commit.BinaryFiles++
+			}
+			added, _ := strconv.Atoi(fields[0])
+			deleted, _ := strconv.Atoi(fields[1])
+			commit.Additions += added
+			commit.Deletions += deleted
+		}
+		// Each commit is only checked once, so being both author and
+		// committer (or co-author) still counts a single contribution
+		if identity != nil && !commit.matches(identity, filter) {
+			continue
+		}
+		commits = append(commits, commit)
 
 	}
+	if parsed == 0 && len(records) > 0 {
+		// Something other than the format came back, don't report it as
+		// no commits
+		return CommitHistory{}, fmt.Errorf("unexpected git log output, %d records and none in the expected format", len(records))
+	}
+	if filter.ExcludeEmpty {
+		commits = excludeEmpty(commits)
+	}
+	if filter.ExcludeReverts {
+		commits = excludeReverts(commits)
+	}
+	if len(commits) == 0 {
+		return CommitHistory{}, errNoCommits
+	}
 
-	return CommitHistory{Author: author, Commits: commits}, nil
+	return CommitHistory{Author: filter.Author, Filter: filter, Commits: commits}, nil
 }
 
-func main() {
-	fmt.Println("Git Contribution Calendar:")
-	yamlFile, err := os.ReadFile("gitcal.conf")
-	if err != nil {
-		fmt.Printf("Error reading config file: %v\n", err)
-		return
+var (
+	authorFlag           = flag.String("author", "", "only count commits whose author matches (overrides the config file)")
+	committerFlag        = flag.String("committer", "", "only count commits whose committer matches")
+	byMeFlag             = flag.Bool("by-me", false, "count commits where the author is either the author or the committer")
+	minLevelFlag         = flag.Int("min-level", 0, "draw days below this intensity level as empty")
+	localeFlag           = flag.String("locale", "en", "language of month and weekday names: de, en, es, fr, it, nl, pl, pt or sv")
+	legendFlag           = flag.Bool("legend", false, "print a legend below the calendar")
+	diffFlag             = flag.Bool("diff", false, "draw each day's change against the same day of the period before the window, green for more and red for fewer")
+	encodingFlag         = flag.String("encoding", encodingAuto, "characters the output may use: utf-8, ascii for terminals that show boxes or question marks, or auto to go by the locale (the console code page on Windows)")
+	asciiFlag            = flag.Bool("ascii", false, "same as --encoding ascii")
+	prettyFlag           = flag.Bool("pretty", false, "frame the title, calendar, legend and a stats line in one colorful box, e.g. for screenshots (implies --legend and --theme github)")
+	themeFlag            = flag.String("theme", "", "colors of the intensity levels: default for the terminal's greens or github (the default with --pretty, overrides the config file)")
+	profileFlag          = flag.String("profile", "", "profile of the config file to use, defaults to the one called default or else the first")
+	noMonthsFlag         = flag.Bool("no-months", false, "leave out the month header (overrides show_months in the config file)")
+	statsFlag            = flag.Bool("stats", false, "print a summary block below the calendar: active days, streaks, peak day and the last commit")
+	excludeRangeFlag     = flag.String("exclude-range", "", "comma separated date ranges like 2026-07-01..2026-07-14 to leave out of streaks and active days, e.g. vacations (overrides the config file)")
+	statsFieldsFlag      = flag.String("stats-fields", "", "comma separated stats for --stats to show, any of "+strings.Join(statNames, ",")+" (implies --stats)")
+	compactStatsFlag     = flag.Bool("compact-stats", false, "print the stats on one line instead of the calendar, e.g. for embedding")
+	sentenceFlag         = flag.Bool("summary-sentence", false, "print a one sentence summary of the last year instead of the calendar")
+	sourceFlag           = flag.String("source", "git", "where to read commits from: git or bitbucket")
+	userFlag             = flag.String("user", "", "account to count commits for with --source bitbucket (defaults to the author)")
+	hostFlag             = flag.String("host", bitbucketCloudHost, "API host for --source bitbucket, anything but Bitbucket Cloud is treated as Bitbucket Server")
+	excludeEmptyFlag     = flag.Bool("exclude-empty", false, "skip commits that don't change any files")
+	excludeRevertsFlag   = flag.Bool("exclude-reverts", false, "skip reverts and the commits they revert (best effort)")
+	repoFlag             = flag.String("repo", "", "repository for --source bitbucket as workspace/repo (PROJECT/repo on Bitbucket Server)")
+	watchFlag            = flag.Bool("watch", false, "redraw the calendar every --interval, reloading the config file when it changes")
+	coauthorsFlag        = flag.Bool("coauthors", false, "also count commits that list the author in a Co-authored-by trailer")
+	countMergesAsFlag    = flag.String("count-merges-as", "full", "how much a merge commit counts: full, half or zero (unlike --no-merges they stay in the history)")
+	coauthorWeightFlag   = flag.String("coauthor-weight", "full", "how line counts of co-authored commits are attributed: full or split")
+	linesFlag            = flag.Bool("lines", false, "print added and deleted lines below the totals")
+	promptFlag           = flag.Bool("prompt", false, "print a single line of recent activity for shell prompts, without a trailing newline")
+	promptDaysFlag       = flag.Int("prompt-days", 20, "days of activity shown by --prompt")
+	promptWidthFlag      = flag.Int("prompt-width", 20, "characters wide the --prompt line is")
+	promptShellFlag      = flag.String("prompt-shell", "", "shell --prompt is embedded in, zsh or bash, so its color codes are marked as taking no space")
+	branchFlag           = flag.String("branch", "", "read history from this branch or ref instead of HEAD")
+	noMergesFlag         = flag.Bool("no-merges", false, "leave out merge commits")
+	firstParentFlag      = flag.Bool("first-parent", false, "only follow the first parent of merges, i.e. the branch's own history")
+	chartFlag            = flag.String("chart", "", "print a bar chart instead of the calendar: weekday")
+	metricFlag           = flag.String("metric", metricCommits, "what a day's intensity measures: commits, or lines added plus deleted")
+	targetTotalFlag      = flag.Int("target-total", 0, "report how far the window is from N contributions and what an even pace would take, instead of the calendar")
+	topDaysFlag          = flag.Int("top-days", 0, "list the N days with the most commits instead of the calendar")
+	dayFlag              = flag.String("day", "", "list every commit of a day (YYYY-MM-DD) instead of the calendar, or below it with --footer-date")
+	footerDateFlag       = flag.Bool("footer-date", false, "print a line below the calendar with the focused day's date and commits, today unless --focus or --day says otherwise")
+	focusFlag            = flag.String("focus", "", "day (YYYY-MM-DD) --footer-date describes (implies --footer-date)")
+	breakdownFlag        = flag.String("breakdown", "", "print how commits are distributed instead of the calendar: commit-size")
+	maxCountFlag         = flag.Int("max-count", 0, "commits in a day that reach the top intensity level, keeps colors comparable across runs")
+	activeThresholdFlag  = flag.Int("active-threshold", 1, "commits a day needs to count as active for streaks, active days and gaps")
+	sinceFirstCommitFlag = flag.Bool("since-first-commit", false, "start the calendar at the first commit, split into years when it doesn't fit")
+	splitYearsFlag       = flag.Bool("split-years", false, "draw one calendar per year from the first commit to today")
+	granularityFlag      = flag.String("granularity", "day", "what a cell stands for: day, or week for a single row with one cell per week")
+	noFutureCellsFlag    = flag.Bool("no-future-cells", false, "end this year's calendar at today instead of drawing the rest of the year as placeholders")
+	sharedScaleFlag      = flag.Bool("shared-scale", true, "scale compared calendars to the busiest day of all of them, false scales each on its own")
+	showTagsFlag         = flag.Bool("show-tags", false, "mark days with annotated tags (releases) by the author on the calendar")
+	linksFlag            = flag.Bool("links", false, "make each day a clickable link to its commits in terminals that support OSC 8 hyperlinks")
+	formatFlag           = flag.String("format", "text", "how the calendar is drawn: text, sixel for an image in terminals that support Sixel graphics, png or json, or several separated by commas like text,png")
+	outputFlag           = flag.String("output", "", "file to write the --format png image to")
+	outputDirFlag        = flag.String("output-dir", "", "directory to write one --format png image and JSON file per calendar to, created if needed")
+	forceSixelFlag       = flag.Bool("force-sixel", false, "draw --format sixel without asking the terminal whether it supports it")
+	styleFlag            = flag.String("style", styleBackground, "how cells are drawn: background, block or hybrid (background and a density glyph)")
+	jsonErrorsFlag       = flag.Bool("json-errors", false, "print errors to stderr as JSON objects with an error message and code")
+	asOfFlag             = flag.String("as-of", "", "draw the calendar as it was at the end of this day (YYYY-MM-DD) in the local time zone")
+	addrFlag             = flag.String("addr", "localhost:8080", "address gitcal serve listens on")
+	corsOriginFlag       = flag.String("cors-origin", "*", "origins gitcal serve allows to read the API, sent as Access-Control-Allow-Origin")
+	intervalFlag         = flag.Duration("interval", 2*time.Second, "how often --watch redraws")
+)
+
+// Path of the config file, relative to the current directory
+const configPath = "gitcal.conf"
+
+// Everything a run needs, resolved from the config file and the flags
+type Settings struct {
+	Filter          LogFilter
+	Render          RenderOptions
+	CoauthorWeight  string    // full or split, see lineTotals
+	Formats         []string  // What --format writes, any of text, sixel, png and json
+	MergeWeight     float64   // What a merge counts for, see mergeWeights
+	ActiveThreshold int       // Commits a day needs to count towards streaks
+	StatsFields     []string  // Lines of the --stats block, see statNames
+	AsOf            time.Time // End of the --as-of day, zero for now
+	Focus           time.Time // Day --footer-date describes, zero for today
+	Source          string    // Where commits come from: git or bitbucket
+	User            string    // Bitbucket account, empty for the author
+}
+
+// Names of the flags given on the command line, which win over the config
+// file even when they're set to their default
+var flagsGiven = make(map[string]bool)
+
+// What makes a day count as active for the stats
+func (settings Settings) activity() activity {
+	return activity{threshold: settings.ActiveThreshold, excluded: settings.Render.Excluded}
+}
+
+// The moment the calendar runs up to, the end of the --as-of day if set
+func (settings Settings) now() time.Time {
+	if !settings.AsOf.IsZero() {
+		return settings.AsOf
 	}
+	return time.Now()
+}
+
+// Last moment of t's day in its zone
+func endOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 23, 59, 59, int(time.Second-time.Nanosecond), t.Location())
+}
+
+// Read and parse the config file
+func loadConfig(path string) (Config, error) {
 	var config Config
+	yamlFile, err := os.ReadFile(path)
+	if err != nil {
+		return config, fmt.Errorf("reading config file: %v", err)
+	}
 	err = yaml.Unmarshal(yamlFile, &config)
 	if err != nil {
-		fmt.Printf("Error parsing config file: %v\n", err)
-		return
+		return config, fmt.Errorf("parsing config file: %v", err)
 	}
-	authorName := config.Author
-	if authorName == "" {
-		fmt.Println("No author specified in config file")
-		return
+	if err := config.applyProfile(*profileFlag); err != nil {
+		return config, err
+	}
+	return config, nil
+}
+
+// Override the top level settings with the ones a profile sets. Without a
+// name the profile called default is used, or else the first one, so a
+// config with profiles always has one applied.
+func (config *Config) applyProfile(name string) error {
+	profiles := config.Profiles
+	if profiles.Kind == 0 {
+		if name != "" {
+			return fmt.Errorf("--profile %q given but the config file has no profiles", name)
+		}
+		return nil
+	}
+	if profiles.Kind != yaml.MappingNode || len(profiles.Content) == 0 {
+		return fmt.Errorf("profiles must map profile names to settings")
+	}
+	// Content alternates between each name and its settings, in file order
+	var names []string
+	selected := -1
+	for i := 0; i < len(profiles.Content); i += 2 {
+		key := profiles.Content[i].Value
+		names = append(names, key)
+		if key == name || (name == "" && key == "default") {
+			selected = i + 1
+		}
+	}
+	if selected == -1 && name != "" {
+		return fmt.Errorf("unknown --profile %q, the config file has %s", name, strings.Join(names, ", "))
+	}
+	if selected == -1 {
+		selected = 1
+	}
+	// Decoding into the loaded config only replaces what the profile sets
+	if err := profiles.Content[selected].Decode(config); err != nil {
+		return fmt.Errorf("parsing profile %s: %v", profiles.Content[selected-1].Value, err)
+	}
+	return nil
+}
+
+// Combine the config with the flags, flags win. Fails when the result is
+// invalid, e.g. a color can't be parsed.
+func resolveSettings(config Config) (Settings, error) {
+	filter := LogFilter{
+		Author:         config.Author,
+		Committer:      *committerFlag,
+		ByMe:           *byMeFlag,
+		ExcludeEmpty:   *excludeEmptyFlag,
+		ExcludeReverts: *excludeRevertsFlag,
+		Coauthors:      *coauthorsFlag,
+		Lines:          *linesFlag || *metricFlag == metricLines || *breakdownFlag != "",
+		Branch:         *branchFlag,
+		NoMerges:       *noMergesFlag,
+		FirstParent:    *firstParentFlag,
+	}
+	if flagsGiven["author"] {
+		// Even when empty, so --author '' clears the config's author
+		filter.Author = *authorFlag
+	} else if filter.Committer != "" && !filter.ByMe {
+		// --committer alone counts what you committed whoever authored it,
+		// the config's author would only AND with it
+		filter.Author = ""
+	}
+	// Only Bitbucket matches commits by --user, git would count everyone's
+	if filter.Author == "" && filter.Committer == "" && (*userFlag == "" || *sourceFlag != "bitbucket") {
+		return Settings{}, fmt.Errorf("no author specified in config file")
+	}
+	if filter.ByMe && (filter.Author == "" || filter.Committer != "") {
+		return Settings{}, fmt.Errorf("--by-me needs an author and can't be combined with --committer")
+	}
+	if filter.Coauthors && filter.Author == "" {
+		return Settings{}, fmt.Errorf("--coauthors needs an author")
+	}
+	mergeWeight, ok := mergeWeights[*countMergesAsFlag]
+	if !ok {
+		return Settings{}, fmt.Errorf("unknown --count-merges-as %q, use full, half or zero", *countMergesAsFlag)
+	}
+	if *coauthorWeightFlag != "full" && *coauthorWeightFlag != "split" {
+		return Settings{}, fmt.Errorf("unknown --coauthor-weight %q, use full or split", *coauthorWeightFlag)
+	}
+
+	renderOpts := RenderOptions{MinLevel: *minLevelFlag, Legend: *legendFlag, LowLabel: "Less", HighLabel: "More", MaxCount: *maxCountFlag}
+	switch *styleFlag {
+	case styleBackground, styleBlock, styleHybrid:
+		renderOpts.Style = *styleFlag
+	default:
+		return Settings{}, fmt.Errorf("unknown --style %q, use background, block or hybrid", *styleFlag)
+	}
+	if _, ok := locales[*localeFlag]; !ok {
+		return Settings{}, fmt.Errorf("unknown --locale %q, use one of %s", *localeFlag, localeList())
+	}
+	renderOpts.Locale = *localeFlag
+	formats := strings.Split(*formatFlag, ",")
+	for i, format := range formats {
+		formats[i] = strings.TrimSpace(format)
+		switch formats[i] {
+		case "text", "sixel", "png", "json":
+		default:
+			return Settings{}, fmt.Errorf("unknown --format %q, use text, sixel, png or json, or several separated by commas", formats[i])
+		}
+	}
+	dataFiles := slices.Contains(formats, "json") && *outputDirFlag != ""
+	theme := *themeFlag
+	if theme == "" {
+		theme = config.Theme
+	}
+	if *prettyFlag {
+		renderOpts.Legend = true
+		if theme == "" {
+			theme = "github"
+		}
+		// The frame needs a text calendar to go around and would cut
+		// through an image or a data dump
+		if !slices.Contains(formats, "text") || slices.Contains(formats, "sixel") || (slices.Contains(formats, "json") && !dataFiles) {
+			return Settings{}, fmt.Errorf("--pretty needs --format text and doesn't work with sixel or json on stdout")
+		}
+	}
+	if theme == "" {
+		theme = "default"
+	}
+	colors, ok := themes[theme]
+	if !ok {
+		return Settings{}, fmt.Errorf("unknown --theme %q, use default or github", theme)
+	}
+	renderOpts.Theme = colors
+	renderOpts.Padding = defaultPadding
+	if config.Padding != nil {
+		for _, side := range config.Padding {
+			if side < 0 {
+				return Settings{}, fmt.Errorf("padding can't be negative")
+			}
+		}
+		renderOpts.Padding = *config.Padding
+	}
+	switch *metricFlag {
+	case metricCommits, metricLines:
+		renderOpts.Metric = *metricFlag
+	default:
+		return Settings{}, fmt.Errorf("unknown --metric %q, use commits or lines", *metricFlag)
+	}
+	switch *granularityFlag {
+	case "day":
+	case "week":
+		// JSON lists days, weeks are only a way of drawing them
+		if slices.Contains(formats, "json") {
+			return Settings{}, fmt.Errorf("--granularity week doesn't work with --format json")
+		}
+		renderOpts.Weekly = true
+	default:
+		return Settings{}, fmt.Errorf("unknown --granularity %q, use day or week", *granularityFlag)
+	}
+	switch encoding := *encodingFlag; {
+	case *asciiFlag || encoding == encodingASCII:
+		renderOpts.ASCII = true
+	case encoding == encodingAuto:
+		renderOpts.ASCII = !outputIsUTF8()
+	case encoding != encodingUTF8:
+		return Settings{}, fmt.Errorf("unknown --encoding %q, use auto, utf-8 or ascii", encoding)
+	}
+	// The change is only drawn as cells, with its own scale
+	if *diffFlag && (len(formats) != 1 || formats[0] != "text" || renderOpts.Weekly || *prettyFlag) {
+		return Settings{}, fmt.Errorf("--diff only works with --format text, without --granularity week or --pretty")
+	}
+	if slices.Contains(formats, "text") && slices.Contains(formats, "sixel") {
+		return Settings{}, fmt.Errorf("--format text and sixel both draw on the terminal, pick one")
+	}
+	// The JSON can't share stdout with anything, with --output-dir it's files
+	if len(formats) > 1 && slices.Contains(formats, "json") && !dataFiles {
+		return Settings{}, fmt.Errorf("--format json with other formats needs --output-dir for the JSON files")
+	}
+	if slices.Contains(formats, "sixel") {
+		// Terminals without Sixel get the normal calendar
+		renderOpts.Sixel = *forceSixelFlag || sixelSupported()
+	}
+	if slices.Contains(formats, "png") && *outputFlag == "" && *outputDirFlag == "" {
+		return Settings{}, fmt.Errorf("--format png needs --output or --output-dir")
+	}
+	if !slices.Contains(formats, "png") && (*outputFlag != "" || (*outputDirFlag != "" && !dataFiles)) {
+		return Settings{}, fmt.Errorf("--output and --output-dir only work with --format png or json")
+	}
+	if *outputFlag != "" && *outputDirFlag != "" {
+		return Settings{}, fmt.Errorf("--output and --output-dir can't be combined")
+	}
+	if *linksFlag && supportsHyperlinks() {
+		renderOpts.LinkURL = config.LinkURL
+		if renderOpts.LinkURL == "" {
+			renderOpts.LinkURL = githubLinkTemplate()
+		}
+		renderOpts.LinkAuthor = filter.Author
+	}
+	renderOpts.NoMonths = *noMonthsFlag || (config.ShowMonths != nil && !*config.ShowMonths)
+	if len(config.LegendLabels) != 0 {
+		if len(config.LegendLabels) != 2 {
+			return Settings{}, fmt.Errorf("legend_labels must be a pair like [Less, More]")
+		}
+		renderOpts.LowLabel, renderOpts.HighLabel = config.LegendLabels[0], config.LegendLabels[1]
+	}
+	if config.EmptyColor == "" {
+		config.EmptyColor = defaultEmptyColor
+	}
+	var err error
+	renderOpts.EmptyColor, err = parseColor(config.EmptyColor)
+	if err != nil {
+		return Settings{}, fmt.Errorf("parsing empty_color: %v", err)
+	}
+	if config.PadColor != "" {
+		renderOpts.PadColor, err = parseColor(config.PadColor)
+		if err != nil {
+			return Settings{}, fmt.Errorf("parsing pad_color: %v", err)
+		}
+	}
+	statsFields := defaultStatsFields
+	if len(config.StatsFields) != 0 {
+		statsFields = config.StatsFields
+	}
+	if *statsFieldsFlag != "" {
+		statsFields = strings.Split(*statsFieldsFlag, ",")
+	}
+	if err := validateStatsFields(statsFields); err != nil {
+		return Settings{}, fmt.Errorf("parsing stats fields: %v", err)
+	}
+	excludeRanges := config.ExcludeRanges
+	if *excludeRangeFlag != "" {
+		excludeRanges = strings.Split(*excludeRangeFlag, ",")
+	}
+	renderOpts.Excluded, err = parseExcludeRanges(excludeRanges)
+	if err != nil {
+		return Settings{}, err
+	}
+	var asOf time.Time
+	if *asOfFlag != "" {
+		day, err := time.ParseInLocation("2006-01-02", *asOfFlag, time.Local)
+		if err != nil {
+			return Settings{}, fmt.Errorf("--as-of must be a date like 2026-01-31, got %q", *asOfFlag)
+		}
+		asOf = endOfDay(day)
+	}
+	var focus time.Time
+	if *focusFlag != "" {
+		focus, err = time.Parse(time.DateOnly, *focusFlag)
+		if err != nil {
+			return Settings{}, fmt.Errorf("--focus must be a date like 2026-01-31, got %q", *focusFlag)
+		}
+		renderOpts.FooterDate = true
+	}
+	renderOpts.FooterDate = renderOpts.FooterDate || *footerDateFlag
+	return Settings{
+		Filter:          filter,
+		Render:          renderOpts,
+		CoauthorWeight:  *coauthorWeightFlag,
+		MergeWeight:     mergeWeight,
+		Formats:         formats,
+		ActiveThreshold: *activeThresholdFlag,
+		StatsFields:     statsFields,
+		AsOf:            asOf,
+		Focus:           focus,
+		Source:          *sourceFlag,
+		User:            *userFlag,
+	}, nil
+}
+
+// Read the commits from the configured source. Commits older than since may
+// be skipped, pass the zero time to get the whole history.
+func fetchHistory(settings Settings, since time.Time) (CommitHistory, error) {
+	filter := settings.Filter
+	switch settings.Source {
+	case "git":
+		commitHistory, err := runGitLog(filter)
+		if err != nil {
+			return CommitHistory{}, sourceError(fmt.Errorf("running git log: %w", err))
+		}
+		if *showTagsFlag {
+			commitHistory.Tags, err = runGitTags(filter)
+			if err != nil {
+				return CommitHistory{}, sourceError(fmt.Errorf("reading tags: %w", err))
+			}
+		}
+		weighMerges(commitHistory.Commits, settings.MergeWeight)
+		return commitHistory, nil
+	case "bitbucket":
+		if filter.Committer != "" || filter.ByMe || filter.ExcludeEmpty || filter.ExcludeReverts || filter.Coauthors || filter.Lines ||
+			filter.Branch != "" || filter.NoMerges || filter.FirstParent || *showTagsFlag {
+			return CommitHistory{}, configError(fmt.Errorf("--committer, --by-me, --exclude-empty, --exclude-reverts, --coauthors, --lines, --metric lines, --breakdown, --branch, --no-merges, --first-parent and --show-tags only work with --source git"))
+		}
+		src := BitbucketSource{Host: *hostFlag, Repo: *repoFlag, User: settings.User}
+		if src.User == "" {
+			src.User = filter.Author
+		}
+		commitHistory, err := fetchBitbucket(src, since)
+		if err != nil {
+			return CommitHistory{}, sourceError(fmt.Errorf("fetching from Bitbucket: %w", err))
+		}
+		weighMerges(commitHistory.Commits, settings.MergeWeight)
+		return commitHistory, nil
+	default:
+		return CommitHistory{}, configError(fmt.Errorf("unknown source %q, use git or bitbucket", settings.Source))
 	}
+}
 
-	// Create a graphql client to connect to GitHub's GraphQL API
-	commitHistory, err := runGitLog(authorName)
+// Fetch the commits and print the requested output once
+func run(settings Settings, now time.Time) error {
+	since := windowStart(now)
+	if *diffFlag {
+		// The previous period is compared against, sources that only
+		// fetch from since (bitbucket) need it too
+		since = since.AddDate(0, 0, -windowDays(lastYearWindow(now)))
+	}
+	if *promptFlag {
+		since = dayOf(now).AddDate(0, 0, -*promptDaysFlag+1)
+	}
+	if *sinceFirstCommitFlag || *splitYearsFlag {
+		since = time.Time{}
+	}
+	commitHistory, err := fetchHistory(settings, since)
 	if err != nil {
-		fmt.Printf("Error running git log: %v\n", err)
+		return err
+	}
+	// Nothing after the last day counts, which only matters with --as-of
+	// or for commits with clocks set ahead
+	commitHistory.Commits = commitsInWindow(commitHistory.Commits, time.Time{}, now)
+	if len(commitHistory.Commits) == 0 {
+		return errNoCommits
+	}
+	if commitHistory.Tags != nil {
+		commitHistory.Tags = tagsInWindow(commitHistory.Tags, time.Time{}, now)
+	}
+	if *promptFlag {
+		fmt.Print(promptLine(commitHistory.Commits, now, *promptDaysFlag, *promptWidthFlag, *promptShellFlag, settings.Render.scaledTo(commitHistory.Commits)))
+		return nil
+	}
+
+	windows := []Window{lastYearWindow(now)}
+	if *sinceFirstCommitFlag {
+		windows = sinceFirstCommitWindows(commitHistory.Commits, now)
+	} else if *splitYearsFlag {
+		windows = yearWindows(firstCommit(commitHistory.Commits), now)
+	}
+	if *noFutureCellsFlag {
+		for i := range windows {
+			windows[i].GridEnd = time.Time{}
+		}
+	}
+	// Stats and charts cover every window together
+	startDate, uptoDate := windows[0].Start, windows[len(windows)-1].End
+	commits := commitsInWindow(commitHistory.Commits, startDate, uptoDate)
+	if *sentenceFlag {
+		fmt.Println(summarySentence(commits, startDate, uptoDate, settings.activity()))
+		return nil
+	}
+	if *compactStatsFlag {
+		// Only a terminal has a width to fit, embeds get the whole line
+		width, _, err := term.GetSize(os.Stdout.Fd())
+		if err != nil {
+			width = 0
+		}
+		fmt.Println(settings.Render.encode(compactStats(commits, settings.activity(), width)))
+		return nil
+	}
+	var listDay time.Time
+	if *dayFlag != "" {
+		day, err := time.Parse(time.DateOnly, *dayFlag)
+		if err != nil {
+			return configError(fmt.Errorf("--day must be a date like 2026-01-31, got %q", *dayFlag))
+		}
+		// With a footer the list goes below the calendar instead
+		if !settings.Render.FooterDate {
+			// Any day of the history, not just the window
+			fmt.Print(settings.Render.encode(dayList(commitHistory.Commits, day, settings.Render)))
+			return nil
+		}
+		listDay = day
+	}
+	if *targetTotalFlag > 0 {
+		fmt.Print(settings.Render.encode(targetReport(commits, *targetTotalFlag, startDate, uptoDate)))
+		return nil
+	}
+	if *topDaysFlag > 0 {
+		fmt.Print(settings.Render.encode(topDaysList(commits, *topDaysFlag, settings.Render)))
+		return nil
+	}
+	switch *breakdownFlag {
+	case "":
+	case "commit-size":
+		fmt.Print(settings.Render.encode(commitSizeChart(commits)))
+		return nil
+	default:
+		return configError(fmt.Errorf("unknown breakdown %q, use commit-size", *breakdownFlag))
+	}
+	switch *chartFlag {
+	case "":
+	case "weekday":
+		fmt.Print(settings.Render.encode(weekdayChart(commits, settings.Render)))
+		return nil
+	default:
+		return configError(fmt.Errorf("unknown chart %q, use weekday", *chartFlag))
+	}
+	images := slices.Contains(settings.Formats, "png")
+	if images && *outputFlag != "" && len(windows) > 1 {
+		return configError(fmt.Errorf("--output writes a single file but there are %d calendars, use --output-dir", len(windows)))
+	}
+	terminal := slices.Contains(settings.Formats, "text") || slices.Contains(settings.Formats, "sixel")
+	data := slices.Contains(settings.Formats, "json")
+	dataFiles := data && *outputDirFlag != ""
+	title := "Git Contribution Calendar"
+	if settings.Filter.Branch != "" {
+		title += " (" + settings.Filter.Branch + ")"
+	}
+	if terminal && !*prettyFlag {
+		// Files or JSON alone are nothing to title, and --pretty puts the
+		// title in its frame
+		fmt.Println(title + ":")
+	}
+	// Several calendars are compared, scale them to the busiest day of all
+	// of them or each to its own, unless --max-count already fixes the scale
+	scaled := len(windows) > 1 && settings.Render.MaxCount == 0
+	sharedMax := settings.Render.busiest(commits)
+	calendars := make([]calendarJSON, 0, len(windows))
+	var pretty []string
+	for i, window := range windows {
+		if *diffFlag {
+			// Every diff is scaled to its own biggest change
+			fmt.Print(diffText(newDiffCalendar(commitHistory, window, settings.Render)))
+			continue
+		}
+		opts := settings.Render
+		if commitHistory.Tags != nil {
+			opts.TagDays = tagDays(commitHistory.Tags)
+		}
+		if scaled && *sharedScaleFlag {
+			opts.MaxCount = sharedMax
+		} else if scaled {
+			opts.MaxCount = opts.busiest(commitsInWindow(commits, window.Start, window.End))
+		} else {
+			opts = opts.scaledTo(commits)
+		}
+		// One legend below the last calendar is enough, unless every
+		// calendar has a scale of its own
+		opts.Legend = opts.Legend && (i == len(windows)-1 || (scaled && !*sharedScaleFlag))
+		// Every format reads the same calendar
+		cal := newCalendar(commitHistory, window, opts)
+		if images {
+			path, err := writePNG(cal)
+			if err != nil {
+				return fmt.Errorf("writing image: %w", err)
+			}
+			fmt.Println("Wrote", path)
+		}
+		if dataFiles {
+			path, err := writeJSON(cal)
+			if err != nil {
+				return fmt.Errorf("writing JSON: %w", err)
+			}
+			fmt.Println("Wrote", path)
+		} else if data {
+			calendars = append(calendars, calendarData(cal))
+		}
+		if terminal && *prettyFlag {
+			pretty = append(pretty, calendarText(cal))
+		} else if terminal {
+			fmt.Print(calendarText(cal))
+		}
+	}
+	if *prettyFlag {
+		fmt.Println(prettyLayout(title, pretty, prettyStats(commits, settings.activity(), settings.Render), settings.Render))
+	}
+	if data && !dataFiles {
+		// One calendar as an object, several as an array of them
+		var out []byte
+		if len(calendars) == 1 {
+			out, err = json.MarshalIndent(calendars[0], "", "  ")
+		} else {
+			out, err = json.MarshalIndent(calendars, "", "  ")
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if settings.Render.FooterDate && terminal {
+		// --focus wins, then the day --day lists
+		focus := settings.Focus
+		if focus.IsZero() {
+			focus = listDay
+		}
+		if focus.IsZero() {
+			focus = now
+		}
+		fmt.Println(footerLine(commitHistory.Commits, focus, settings.Render))
+		if !listDay.IsZero() {
+			fmt.Print(settings.Render.encode(dayList(commitHistory.Commits, listDay, settings.Render)))
+		}
+	}
+	if *linesFlag {
+		// Splitting only matters once other people's commits are included
+		split := settings.Filter.Coauthors && settings.CoauthorWeight == "split"
+		added, deleted := lineTotals(commits, split)
+		fmt.Printf("+%.0f -%.0f lines (net %+.0f)\n", added, deleted, added-deleted)
+	}
+	if *statsFlag || *statsFieldsFlag != "" {
+		fmt.Println()
+		fmt.Print(statsBlock(settings.StatsFields, commits, commitHistory.Commits, commitHistory.Tags, startDate, uptoDate, now, settings.activity()))
+	}
+	return nil
+}
+
+// Redraw every interval until interrupted. The config file is reloaded when
+// its modification time changes, a config that fails to load or validate is
+// reported and the previous settings stay in use.
+func watch(settings Settings, interval time.Duration) {
+	var modTime time.Time
+	if info, err := os.Stat(configPath); err == nil {
+		modTime = info.ModTime()
+	}
+	var configErr error
+	for {
+		if info, err := os.Stat(configPath); err == nil && !info.ModTime().Equal(modTime) {
+			modTime = info.ModTime()
+			config, err := loadConfig(configPath)
+			if err == nil {
+				var reloaded Settings
+				reloaded, err = resolveSettings(config)
+				if err == nil {
+					settings = reloaded
+				}
+			}
+			configErr = err
+		}
+
+		fmt.Print("\033[H\033[2J") // Clear the screen before redrawing
+		if err := run(settings, settings.now()); err != nil {
+			reportError(err)
+		}
+		if configErr != nil {
+			reportError(configError(fmt.Errorf("keeping the previous config: %w", configErr)))
+		}
+		time.Sleep(interval)
+	}
+}
+
+func main() {
+	// "gitcal tui [flags]" opens the interactive calendar and
+	// "gitcal serve [flags]" serves the data over HTTP
+	args := os.Args[1:]
+	interactive := len(args) > 0 && args[0] == "tui"
+	serving := len(args) > 0 && args[0] == "serve"
+	if interactive || serving {
+		args = args[1:]
+	}
+	flag.CommandLine.Parse(args)
+	flag.Visit(func(f *flag.Flag) { flagsGiven[f.Name] = true })
+
+	if *minLevelFlag < 0 || *minLevelFlag >= len(greens) {
+		fail(configError(fmt.Errorf("--min-level must be between 0 and %d", len(greens)-1)))
+	}
+	if *promptDaysFlag < 1 || *promptWidthFlag < 1 {
+		fail(configError(fmt.Errorf("--prompt-days and --prompt-width must be at least 1")))
+	}
+	if _, ok := promptEscapes[*promptShellFlag]; !ok {
+		fail(configError(fmt.Errorf("--prompt-shell must be zsh or bash, got %q", *promptShellFlag)))
+	}
+	if *activeThresholdFlag < 1 {
+		fail(configError(fmt.Errorf("--active-threshold must be at least 1")))
+	}
+	if *topDaysFlag < 0 || *targetTotalFlag < 0 {
+		fail(configError(fmt.Errorf("--top-days and --target-total must be at least 1")))
+	}
+	if *maxCountFlag < 0 {
+		fail(configError(fmt.Errorf("--max-count must be at least 1")))
+	}
+	// gitcal serve can still switch to Bitbucket per request with ?source=
+	if *sourceFlag != "bitbucket" && !serving && (flagsGiven["user"] || flagsGiven["repo"] || flagsGiven["host"]) {
+		fail(configError(fmt.Errorf("--user, --repo and --host only work with --source bitbucket")))
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		fail(configError(err))
+	}
+	settings, err := resolveSettings(config)
+	if err != nil {
+		fail(configError(err))
+	}
+
+	now := settings.now()
+	if interactive && settings.Render.Weekly {
+		fail(configError(fmt.Errorf("gitcal tui moves over days, --granularity week doesn't work with it")))
+	}
+	if interactive {
+		// The TUI can page back through every year, so fetch all of it
+		commitHistory, err := fetchHistory(settings, time.Time{})
+		if err != nil {
+			fail(err)
+		}
+		if err := runTUI(commitHistory, now, settings.Render); err != nil {
+			fail(fmt.Errorf("running TUI: %w", err))
+		}
 		return
 	}
-	now := time.Now()
-	printCommitHistory(commitHistory, now)
+	if serving {
+		if err := serve(settings, *addrFlag); err != nil {
+			fail(fmt.Errorf("serving: %w", err))
+		}
+		return
+	}
+	if *watchFlag {
+		watch(settings, *intervalFlag)
+		return
+	}
+	if err := run(settings, now); err != nil {
+		fail(err)
+	}
 }
@@ -0,0 +1,377 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A stand-in for git's answer to one call
+type gitReply struct {
+	out string
+	err error
+}
+
+// Swap gitRunner for one answering with replies in turn, the last reply is
+// repeated once they run out. Returns the arguments of each call.
+func fakeGit(t *testing.T, replies ...gitReply) *[][]string {
+	t.Helper()
+	calls := new([][]string)
+	original := gitRunner
+	gitRunner = func(args ...string) ([]byte, error) {
+		reply := replies[min(len(*calls), len(replies)-1)]
+		*calls = append(*calls, args)
+		return []byte(reply.out), reply.err
+	}
+	t.Cleanup(func() { gitRunner = original })
+	return calls
+}
+
+func TestRunGitRetriesLockErrors(t *testing.T) {
+	locked := errors.New("exit status 128: fatal: Unable to create '/repo/.git/index.lock': File exists.")
+	calls := fakeGit(t, gitReply{err: locked}, gitReply{out: "ok"})
+
+	out, err := runGit("status")
+	if err != nil || string(out) != "ok" {
+		t.Fatalf("runGit = %q, %v, want ok after a retry", out, err)
+	}
+	if len(*calls) != 2 {
+		t.Errorf("git ran %d times, want 2", len(*calls))
+	}
+}
+
+func TestRunGitDoesntRetryOtherErrors(t *testing.T) {
+	notRepo := errors.New("exit status 128: fatal: not a git repository (or any of the parent directories): .git")
+	calls := fakeGit(t, gitReply{err: notRepo}, gitReply{out: "ok"})
+
+	if _, err := runGit("status"); !errors.Is(err, notRepo) {
+		t.Fatalf("runGit error = %v, want %v", err, notRepo)
+	}
+	if len(*calls) != 1 {
+		t.Errorf("git ran %d times, want 1", len(*calls))
+	}
+}
+
+func TestEndOfDay(t *testing.T) {
+	zone := time.FixedZone("UTC+2", 2*60*60)
+	end := endOfDay(time.Date(2026, 10, 14, 9, 30, 0, 0, zone))
+	if want := time.Date(2026, 10, 14, 23, 59, 59, 999999999, zone); !end.Equal(want) || end.Location() != zone {
+		t.Errorf("endOfDay = %v, want %v", end, want)
+	}
+	if next := end.Add(time.Nanosecond); next.Day() != 15 {
+		t.Errorf("a nanosecond after endOfDay is %v, want the next day", next)
+	}
+}
+
+func TestAsOfWindow(t *testing.T) {
+	today := time.Date(2026, 10, 14, 9, 0, 0, 0, time.Local)
+	// A commit this morning and one on the first day of yesterday's window
+	commits := []Commit{
+		{Weight: 1, Timestamp: today},
+		{Weight: 1, Timestamp: today.AddDate(0, 0, -rows*columns)},
+	}
+	tests := []struct {
+		name string
+		asOf time.Time
+		want time.Time // The only commit in the window
+	}{
+		{"yesterday", endOfDay(today.AddDate(0, 0, -1)), commits[1].Timestamp},
+		{"today", endOfDay(today), commits[0].Timestamp},
+	}
+	for _, test := range tests {
+		now := Settings{AsOf: test.asOf}.now()
+		if !now.Equal(test.asOf) {
+			t.Errorf("%s: now = %v, want the --as-of day's end %v", test.name, now, test.asOf)
+		}
+		window := lastYearWindow(now)
+		if days := int(dayOf(window.End).Sub(dayOf(window.Start)).Hours()/24) + 1; days != rows*columns {
+			t.Errorf("%s: window has %d days, want %d", test.name, days, rows*columns)
+		}
+		inWindow := commitsInWindow(commits, window.Start, window.End)
+		if len(inWindow) != 1 || !inWindow[0].Timestamp.Equal(test.want) {
+			t.Errorf("%s: commits in window %s..%s = %v, want only the one from %v", test.name,
+				window.Start.Format(time.DateOnly), window.End.Format(time.DateOnly), inWindow, test.want)
+		}
+	}
+}
+
+func TestNowWithoutAsOf(t *testing.T) {
+	before := time.Now()
+	if now := (Settings{}).now(); now.Before(before) || now.After(time.Now()) {
+		t.Errorf("now without --as-of = %v, want the current time", now)
+	}
+}
+
+// A record of git log's output for logFormat, fields in logFields order
+// followed by any lines after the header
+func logRecord(fields []string, lines ...string) string {
+	return "\x1e" + strings.Join(fields, "\t") + "\n" + strings.Join(lines, "\n") + "\n"
+}
+
+func TestRunGitLogSkipsSignatureLines(t *testing.T) {
+	header := []string{"abc1234", "2026-07-01T10:00:00+02:00", "Ann", "ann@example.com", "Ann", "ann@example.com", "def5678", "", "Sign everything"}
+	fakeGit(t, gitReply{out: logRecord(header,
+		"gpg: Signature made Wed Jul  1 10:00:00 2026 CEST",
+		"gpg:                using RSA key 0123456789ABCDEF",
+		"gpg: Good signature from \"Ann <ann@example.com>\" [ultimate]",
+		"",
+		"3\t1\tmain.go",
+		"-\t-\tlogo.png",
+		"10\t0\tREADME.md",
+	)})
+
+	history, err := runGitLog(LogFilter{Lines: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history.Commits) != 1 {
+		t.Fatalf("got %d commits, want 1", len(history.Commits))
+	}
+	commit := history.Commits[0]
+	if commit.Hash != "abc1234" || commit.Subject != "Sign everything" {
+		t.Errorf("commit = %s %q, want abc1234 \"Sign everything\"", commit.Hash, commit.Subject)
+	}
+	if commit.FilesChanged != 3 || commit.BinaryFiles != 1 || commit.Additions != 13 || commit.Deletions != 1 {
+		t.Errorf("files %d, binary %d, +%d -%d, want files 3, binary 1, +13 -1",
+			commit.FilesChanged, commit.BinaryFiles, commit.Additions, commit.Deletions)
+	}
+}
+
+// Header fields of a commit in logFields order, the ones not given are filled
+// in with a plain commit by Ann
+func logHeader(fields map[int]string) []string {
+	header := make([]string, len(logFields))
+	header[fieldHash] = "abc1234"
+	header[fieldDate] = "2026-07-01T10:00:00+02:00"
+	header[fieldAuthor], header[fieldAuthorEmail] = "Ann", "ann@example.com"
+	header[fieldCommitter], header[fieldCommitterEmail] = "Ann", "ann@example.com"
+	header[fieldParents] = "def5678"
+	header[fieldSubject] = "Fix the build"
+	for field, value := range fields {
+		header[field] = value
+	}
+	return header
+}
+
+func TestRunGitLogParsesFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		fields    map[int]string
+		subject   string
+		parents   int
+		coauthors []string
+	}{
+		{name: "plain", subject: "Fix the build", parents: 1},
+		{name: "empty subject", fields: map[int]string{fieldSubject: ""}, subject: "", parents: 1},
+		{name: "tabs in subject", fields: map[int]string{fieldSubject: "Align\tcolumns\t"}, subject: "Align\tcolumns\t", parents: 1},
+		{name: "root commit", fields: map[int]string{fieldParents: ""}, subject: "Fix the build", parents: 0},
+		{name: "merge", fields: map[int]string{fieldParents: "def5678 0123abc"}, subject: "Fix the build", parents: 2},
+		{
+			name:      "co-authors",
+			fields:    map[int]string{fieldCoauthors: "Bob <bob@example.com>\x1f Cy <cy@example.com>\x1f"},
+			subject:   "Fix the build",
+			parents:   1,
+			coauthors: []string{"Bob <bob@example.com>", "Cy <cy@example.com>"},
+		},
+	}
+	for _, test := range tests {
+		fakeGit(t, gitReply{out: logRecord(logHeader(test.fields))})
+		history, err := runGitLog(LogFilter{})
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if len(history.Commits) != 1 {
+			t.Errorf("%s: got %d commits, want 1", test.name, len(history.Commits))
+			continue
+		}
+		commit := history.Commits[0]
+		if commit.Hash != "abc1234" || commit.Author != "Ann" || commit.AuthorEmail != "ann@example.com" ||
+			commit.Committer != "Ann" || commit.CommitterEmail != "ann@example.com" {
+			t.Errorf("%s: commit %s by %s <%s>, committed by %s <%s>, want abc1234 by Ann", test.name,
+				commit.Hash, commit.Author, commit.AuthorEmail, commit.Committer, commit.CommitterEmail)
+		}
+		if want := time.Date(2026, 7, 1, 8, 0, 0, 0, time.UTC); !commit.Timestamp.Equal(want) {
+			t.Errorf("%s: timestamp %v, want %v", test.name, commit.Timestamp, want)
+		}
+		if commit.Subject != test.subject {
+			t.Errorf("%s: subject %q, want %q", test.name, commit.Subject, test.subject)
+		}
+		if commit.Parents != test.parents {
+			t.Errorf("%s: %d parents, want %d", test.name, commit.Parents, test.parents)
+		}
+		if strings.Join(commit.Coauthors, ", ") != strings.Join(test.coauthors, ", ") {
+			t.Errorf("%s: co-authors %q, want %q", test.name, commit.Coauthors, test.coauthors)
+		}
+	}
+}
+
+func TestRunGitLogRejectsUnknownOutput(t *testing.T) {
+	// A header missing the subject, e.g. from an older format
+	fakeGit(t, gitReply{out: logRecord(logHeader(nil)[:fieldSubject])})
+	if _, err := runGitLog(LogFilter{}); err == nil || errors.Is(err, errNoCommits) {
+		t.Errorf("runGitLog error = %v, want one about the output", err)
+	}
+	fakeGit(t, gitReply{out: ""})
+	if _, err := runGitLog(LogFilter{}); !errors.Is(err, errNoCommits) {
+		t.Errorf("runGitLog error on empty output = %v, want %v", err, errNoCommits)
+	}
+}
+
+// Set a flag as if it was given on the command line for the rest of the test
+func setFlag(t *testing.T, name, value string) {
+	t.Helper()
+	original := flag.Lookup(name).Value.String()
+	if err := flag.Set(name, value); err != nil {
+		t.Fatal(err)
+	}
+	given := flagsGiven[name]
+	flagsGiven[name] = true
+	t.Cleanup(func() {
+		flag.Set(name, original)
+		flagsGiven[name] = given
+	})
+}
+
+// Make a repository in a temporary directory and change into it. Each commit
+// is "author/committer", both names with an @example.com email.
+func gitRepo(t *testing.T, commits ...string) {
+	t.Helper()
+	t.Chdir(t.TempDir())
+	git := func(env []string, args ...string) {
+		cmd := exec.Command("git", args...)
+		cmd.Env = append(os.Environ(), env...)
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	git(nil, "init", "-q")
+	for i, commit := range commits {
+		author, committer, _ := strings.Cut(commit, "/")
+		git([]string{
+			"GIT_AUTHOR_NAME=" + author, "GIT_AUTHOR_EMAIL=" + strings.ToLower(author) + "@example.com",
+			"GIT_COMMITTER_NAME=" + committer, "GIT_COMMITTER_EMAIL=" + strings.ToLower(committer) + "@example.com",
+		}, "commit", "-q", "--allow-empty", "--no-gpg-sign", "-m", fmt.Sprintf("Commit %d", i))
+	}
+}
+
+func TestCommitterFlagIgnoresConfigAuthor(t *testing.T) {
+	gitRepo(t, "Ann/Ann", "Bob/Ann", "Bob/Bob")
+	tests := []struct {
+		name  string
+		flags map[string]string
+		want  int
+	}{
+		{"config author", nil, 1},
+		{"committer", map[string]string{"committer": "Ann"}, 2},
+		{"author and committer", map[string]string{"author": "Bob", "committer": "Ann"}, 1},
+		{"cleared author", map[string]string{"author": "", "committer": "Bob"}, 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for name, value := range test.flags {
+				setFlag(t, name, value)
+			}
+			settings, err := resolveSettings(Config{Author: "Ann"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			history, err := runGitLog(settings.Filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(history.Commits) != test.want {
+				t.Errorf("counted %d commits with %s, want %d", len(history.Commits), settings.Filter, test.want)
+			}
+		})
+	}
+}
+
+func TestMonthHeaderLinesUpWithWeeks(t *testing.T) {
+	opts := RenderOptions{Locale: "en", Padding: defaultPadding}
+	gridStart := weekOf(day(t, "2025-10-15"))
+	header := monthHeader(gridStart, maxWeeks, opts)
+
+	// Each name starts above the grid column of the first week in its month
+	offset := 1 + opts.Padding[3]
+	names := 0
+	for week := range maxWeeks {
+		start := gridStart.AddDate(0, 0, week*rows)
+		column := offset + week*3
+		newMonth := week == 0 || start.AddDate(0, 0, -rows).Month() != start.Month()
+		name := strings.TrimSpace(opts.monthName(start.Month()))
+		if got := strings.TrimSpace(header[min(column, len(header)):min(column+3, len(header))]); newMonth && got != name {
+			t.Errorf("week of %s: %q above it, want %q", start.Format(time.DateOnly), got, name)
+		} else if !newMonth && got != "" {
+			t.Errorf("week of %s: %q above it, want nothing", start.Format(time.DateOnly), got)
+		}
+		if newMonth {
+			names++
+		}
+	}
+	if names != 13 {
+		t.Errorf("%d names, want 13 from Oct to Oct", names)
+	}
+	if width := offset + maxWeeks*3; len(header) > width {
+		t.Errorf("header is %d wide, want at most %d", len(header), width)
+	}
+}
+
+func TestMonthHeaderSkipsCrowdedNames(t *testing.T) {
+	opts := RenderOptions{Locale: "en", Padding: defaultPadding}
+	// The first week is the last one starting in October
+	header := monthHeader(weekOf(day(t, "2025-10-29")), 6, opts)
+	if want := "   Oct"; header != want {
+		t.Errorf("header = %q, want %q without the November right after", header, want)
+	}
+}
+
+func TestUserFlagIsOnlyAnIdentityForBitbucket(t *testing.T) {
+	setFlag(t, "user", "zzz")
+	if _, err := resolveSettings(Config{}); err == nil {
+		t.Error("--user without an author was accepted for --source git")
+	}
+	setFlag(t, "source", "bitbucket")
+	settings, err := resolveSettings(Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.User != "zzz" {
+		t.Errorf("user = %q, want zzz", settings.User)
+	}
+}
+
+func TestPrettyNeedsText(t *testing.T) {
+	setFlag(t, "pretty", "true")
+	for format, ok := range map[string]bool{"text": true, "text,png": true, "text,json": true, "png": false, "json": false, "sixel": false} {
+		setFlag(t, "format", format)
+		// Files need somewhere to go
+		outputDir := ""
+		if format != "text" && format != "sixel" {
+			outputDir = t.TempDir()
+		}
+		setFlag(t, "output-dir", outputDir)
+		if _, err := resolveSettings(Config{Author: "Ann"}); (err == nil) != ok {
+			t.Errorf("--pretty --format %s: error %v, want accepted %v", format, err, ok)
+		}
+	}
+}
+
+func TestBranchErrors(t *testing.T) {
+	gitRepo(t, "Ann/Ann")
+	if _, err := runGitLog(LogFilter{Branch: "nope"}); err == nil || err.Error() != `branch "nope" doesn't exist` {
+		t.Errorf("missing branch: error %v, want it to not exist", err)
+	}
+	// Outside of a repository git's own error comes through
+	t.Chdir(t.TempDir())
+	t.Setenv("GIT_CEILING_DIRECTORIES", os.TempDir())
+	_, err := runGitLog(LogFilter{Branch: "main"})
+	if err == nil || !strings.Contains(err.Error(), "not a git repository") {
+		t.Errorf("outside a repository: error %v, want git's", err)
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// A palette entry, either one of the terminal's background attributes or a
+// 24-bit color. Cells use it as a background or a foreground depending on
+// the style.
+type paletteColor struct {
+	attr    color.Attribute // Background attribute, unless rgb is set
+	r, g, b int
+	rgb     bool
+}
+
+// Color for drawing the cell background
+func (p paletteColor) bg() *color.Color {
+	if p.rgb {
+		return color.BgRGB(p.r, p.g, p.b)
+	}
+	return color.New(p.attr)
+}
+
+// Color for drawing glyphs, background attributes map onto their
+// foreground counterparts which are 10 lower (e.g. BgGreen and FgGreen)
+func (p paletteColor) fg() *color.Color {
+	if p.rgb {
+		return color.RGB(p.r, p.g, p.b)
+	}
+	return color.New(p.attr - 10)
+}
+
+// Named background colors accepted in the config file
+var namedColors = map[string]color.Attribute{
+	"black":   color.BgBlack,
+	"red":     color.BgRed,
+	"green":   color.BgGreen,
+	"yellow":  color.BgYellow,
+	"blue":    color.BgBlue,
+	"magenta": color.BgMagenta,
+	"cyan":    color.BgCyan,
+	"white":   color.BgWhite,
+	"gray":    color.BgHiBlack,
+	"grey":    color.BgHiBlack,
+}
+
+// Parse a config color, either a name from namedColors or "#rrggbb"
+func parseColor(value string) (*paletteColor, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if attr, ok := namedColors[value]; ok {
+		return &paletteColor{attr: attr}, nil
+	}
+	var r, g, b int
+	if len(value) == 7 && value[0] == '#' {
+		if _, err := fmt.Sscanf(value, "#%02x%02x%02x", &r, &g, &b); err == nil {
+			return &paletteColor{r: r, g: g, b: b, rgb: true}, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown color %q, use a name like \"gray\" or a hex value like \"#161b22\"", value)
+}
+
+// xterm's default values for the attributes, to draw them in images
+var attributeRGB = map[color.Attribute][3]int{
+	color.BgBlack:     {0, 0, 0},
+	color.BgRed:       {205, 0, 0},
+	color.BgGreen:     {0, 205, 0},
+	color.BgYellow:    {205, 205, 0},
+	color.BgBlue:      {0, 0, 238},
+	color.BgMagenta:   {205, 0, 205},
+	color.BgCyan:      {0, 205, 205},
+	color.BgWhite:     {229, 229, 229},
+	color.BgHiBlack:   {127, 127, 127},
+	color.BgHiRed:     {255, 0, 0},
+	color.BgHiGreen:   {0, 255, 0},
+	color.BgHiYellow:  {255, 255, 0},
+	color.BgHiBlue:    {92, 92, 255},
+	color.BgHiMagenta: {255, 0, 255},
+	color.BgHiCyan:    {0, 255, 255},
+	color.BgHiWhite:   {255, 255, 255},
+}
+
+// Red, green and blue of the color, attributes get xterm's defaults
+func (p paletteColor) rgbValues() (int, int, int) {
+	if p.rgb {
+		return p.r, p.g, p.b
+	}
+	values := attributeRGB[p.attr]
+	return values[0], values[1], values[2]
+}
+
+// Colors for the levels above 0 per --theme, nil keeps the terminal's
+// greens. Level 0 stays empty_color in every theme.
+var themes = map[string][]paletteColor{
+	"default": nil,
+	// GitHub's contribution graph in dark mode
+	"github": {
+		{r: 0x0e, g: 0x44, b: 0x29, rgb: true},
+		{r: 0x00, g: 0x6d, b: 0x32, rgb: true},
+		{r: 0x26, g: 0xa6, b: 0x41, rgb: true},
+		{r: 0x39, g: 0xd3, b: 0x53, rgb: true},
+	},
+}
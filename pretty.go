@@ -0,0 +1,49 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Frame and title of the --pretty layout, in the github theme's greens
+var (
+	prettyFrame = lipgloss.NewStyle().
+			Border(lipgloss.RoundedBorder()).
+			BorderForeground(lipgloss.Color("#26a641")).
+			Padding(1, 2)
+	prettyTitle = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("#39d353"))
+	prettyDim   = lipgloss.NewStyle().Foreground(lipgloss.Color("#8b949e"))
+)
+
+// Emoji in front of each part of compactParts, in the same order
+var prettyIcons = []string{"📦", "📅", "🔥", "⭐"}
+
+// The curated stats line of --pretty, the compact stats with an emoji each
+// unless the output is ASCII
+func prettyStats(commits []Commit, rule activity, opts RenderOptions) string {
+	parts := compactParts(commits, rule)
+	for i := range parts {
+		if !opts.ASCII {
+			parts[i] = prettyIcons[i] + " " + parts[i]
+		}
+	}
+	return opts.encode(strings.Join(parts, compactSeparator))
+}
+
+// Title, the calendars with their legends and totals and the stats line in
+// one rounded frame, or one of ASCII characters
+func prettyLayout(title string, calendars []string, stats string, opts RenderOptions) string {
+	frame := prettyFrame
+	if opts.ASCII {
+		frame = frame.Border(lipgloss.ASCIIBorder())
+	} else {
+		title = "🌱 " + title
+	}
+	parts := []string{prettyTitle.Render(title)}
+	for _, calendar := range calendars {
+		parts = append(parts, strings.TrimSuffix(calendar, "\n"))
+	}
+	parts = append(parts, prettyDim.Render(stats))
+	return frame.Render(strings.Join(parts, "\n\n"))
+}
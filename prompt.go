@@ -0,0 +1,71 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// 256-color foregrounds for each level in the prompt, close to greens
+var promptColors = []int{238, 22, 28, 34, 40}
+
+// Used instead of colors when NO_COLOR is set
+var promptGlyphs = []rune(" ░▒▓█")
+
+// What --prompt-shell wraps each escape code in so the shell doesn't count
+// it towards the prompt's width: zsh's %{ %} and the \001 \002 that bash
+// turns \[ \] into, which readline also understands in command output
+var promptEscapes = map[string][2]string{
+	"":     {"", ""},
+	"zsh":  {"%{", "%}"},
+	"bash": {"\x01", "\x02"},
+}
+
+// Recent activity as a single line of width blocks for shell prompts, one
+// per day of the last days up to uptoDate. With more days than blocks each
+// block covers several days and shows the busiest of them, with fewer the
+// line is padded on the left so it keeps its width. A color code is only
+// written when the level changes and there's no trailing newline. shell is
+// a key of promptEscapes.
+func promptLine(commits []Commit, uptoDate time.Time, days, width int, shell string, opts RenderOptions) string {
+	escape := func(code string) string {
+		return promptEscapes[shell][0] + code + promptEscapes[shell][1]
+	}
+	// Prompts capture the output through a pipe, so unlike the calendar
+	// colors aren't turned off just because stdout isn't a terminal
+	noColor := os.Getenv("NO_COLOR") != ""
+	counts := dailyValues(commits, opts.Metric)
+	first := dayOf(uptoDate).AddDate(0, 0, -days+1)
+
+	cells := min(days, width)
+	line := strings.Repeat(" ", width-cells)
+	current := -1 // Level of the color code last written
+	for cell := range cells {
+		// Spread the days evenly over the cells
+		busiest := 0
+		for day := cell * days / cells; day < (cell+1)*days/cells; day++ {
+			busiest = max(busiest, counts[first.AddDate(0, 0, day)])
+		}
+		level := opts.level(busiest)
+
+		if opts.ASCII {
+			// Only the glyphs show the level, colors or not
+			line += string(asciiGlyphs[level])
+			continue
+		}
+		if noColor {
+			line += string(promptGlyphs[level])
+			continue
+		}
+		if level != current {
+			line += escape(fmt.Sprintf("\x1b[38;5;%dm", promptColors[level]))
+			current = level
+		}
+		line += "■"
+	}
+	if current != -1 {
+		line += escape("\x1b[0m")
+	}
+	return line
+}
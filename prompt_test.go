@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPromptLineMarksEscapes(t *testing.T) {
+	t.Setenv("NO_COLOR", "")
+	commits := commitsOn(t, "2026-07-01")
+	upto := day(t, "2026-07-02")
+
+	tests := map[string]string{
+		"":     "\x1b[38;5;22m■\x1b[38;5;238m■\x1b[0m",
+		"zsh":  "%{\x1b[38;5;22m%}■%{\x1b[38;5;238m%}■%{\x1b[0m%}",
+		"bash": "\x01\x1b[38;5;22m\x02■\x01\x1b[38;5;238m\x02■\x01\x1b[0m\x02",
+	}
+	for shell, want := range tests {
+		if got := promptLine(commits, upto, 2, 2, shell, RenderOptions{}); got != want {
+			t.Errorf("promptLine for %q = %q, want %q", shell, got, want)
+		}
+	}
+}
+
+func TestPromptLineKeepsItsWidth(t *testing.T) {
+	t.Setenv("NO_COLOR", "1")
+	commits := commitsOn(t, "2026-07-01")
+	for _, days := range []int{1, 5, 40} {
+		line := promptLine(commits, day(t, "2026-07-02"), days, 20, "zsh", RenderOptions{})
+		if width := len([]rune(line)); width != 20 || strings.Contains(line, "%{") {
+			t.Errorf("%d days: %q is %d wide, want 20 without escapes", days, line, width)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// What makes a day count for streaks, active days and breaks. Excluded
+// days, e.g. vacations, are neutral: never active, they don't break a
+// streak and don't add to a break.
+type activity struct {
+	threshold int                // Commits a day needs to be active
+	excluded  map[time.Time]bool // Days left out, keyed by dayOf
+}
+
+// First day after day that isn't excluded
+func (a activity) next(day time.Time) time.Time {
+	day = day.AddDate(0, 0, 1)
+	for a.excluded[day] {
+		day = day.AddDate(0, 0, 1)
+	}
+	return day
+}
+
+// Last day before day that isn't excluded
+func (a activity) previous(day time.Time) time.Time {
+	day = day.AddDate(0, 0, -1)
+	for a.excluded[day] {
+		day = day.AddDate(0, 0, -1)
+	}
+	return day
+}
+
+// Days in [startDate, uptoDate] that aren't excluded
+func (a activity) days(startDate, uptoDate time.Time) int {
+	days := 0
+	for day := dayOf(startDate); !day.After(dayOf(uptoDate)); day = day.AddDate(0, 0, 1) {
+		if !a.excluded[day] {
+			days++
+		}
+	}
+	return days
+}
+
+// Parse ranges like "2026-07-01..2026-07-14" into the days they cover, both
+// ends included
+func parseExcludeRanges(ranges []string) (map[time.Time]bool, error) {
+	days := make(map[time.Time]bool)
+	for _, value := range ranges {
+		from, to, found := strings.Cut(strings.TrimSpace(value), "..")
+		start, startErr := time.Parse(time.DateOnly, from)
+		end, endErr := time.Parse(time.DateOnly, to)
+		if !found || startErr != nil || endErr != nil {
+			return nil, fmt.Errorf("exclude range %q should look like 2026-07-01..2026-07-14", value)
+		}
+		if end.Before(start) {
+			return nil, fmt.Errorf("exclude range %q ends before it starts", value)
+		}
+		for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+			days[day] = true
+		}
+	}
+	return days, nil
+}
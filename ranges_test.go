@@ -0,0 +1,123 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// Parse a day like "2026-07-01", failing the test if it doesn't
+func day(t *testing.T, value string) time.Time {
+	t.Helper()
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed
+}
+
+// One full weight commit at noon of each day given, repeat a day for more
+func commitsOn(t *testing.T, days ...string) []Commit {
+	t.Helper()
+	commits := make([]Commit, 0, len(days))
+	for _, value := range days {
+		commits = append(commits, Commit{Parents: 1, Weight: 1, Timestamp: day(t, value).Add(12 * time.Hour)})
+	}
+	return commits
+}
+
+// An activity rule with a threshold of one commit and the given ranges excluded
+func excluding(t *testing.T, ranges ...string) activity {
+	t.Helper()
+	excluded, err := parseExcludeRanges(ranges)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return activity{threshold: 1, excluded: excluded}
+}
+
+func TestActivityDays(t *testing.T) {
+	rule := excluding(t, "2026-07-04..2026-07-10")
+	tests := []struct {
+		from, upto string
+		want       int
+	}{
+		{"2026-07-01", "2026-07-12", 5},
+		{"2026-07-04", "2026-07-10", 0},
+		{"2026-07-03", "2026-07-11", 2},
+		{"2026-07-12", "2026-07-01", 0},
+	}
+	for _, test := range tests {
+		if got := rule.days(day(t, test.from), day(t, test.upto)); got != test.want {
+			t.Errorf("days(%s, %s) = %d, want %d", test.from, test.upto, got, test.want)
+		}
+	}
+}
+
+func TestStreakSpansExcludedRange(t *testing.T) {
+	commits := commitsOn(t, "2026-07-01", "2026-07-02", "2026-07-03", "2026-07-11", "2026-07-12")
+	rule := excluding(t, "2026-07-04..2026-07-10")
+
+	if length, start := longestStreak(commits, rule); length != 5 || !start.Equal(day(t, "2026-07-01")) {
+		t.Errorf("longestStreak = %d from %s, want 5 from 2026-07-01", length, start.Format(time.DateOnly))
+	}
+	// Without the vacation the week off breaks the streak
+	if length, _ := longestStreak(commits, activity{threshold: 1}); length != 3 {
+		t.Errorf("longestStreak without excluded days = %d, want 3", length)
+	}
+	for upto, want := range map[string]int{"2026-07-12": 5, "2026-07-13": 5, "2026-07-14": 0} {
+		if got := currentStreak(commits, rule, day(t, upto)); got != want {
+			t.Errorf("currentStreak up to %s = %d, want %d", upto, got, want)
+		}
+	}
+	// Up to the middle of the vacation the streak before it still counts
+	if got := currentStreak(commits, rule, day(t, "2026-07-07")); got != 3 {
+		t.Errorf("currentStreak up to 2026-07-07 = %d, want 3", got)
+	}
+}
+
+func TestCommitsOnExcludedDaysAreNeutral(t *testing.T) {
+	commits := commitsOn(t, "2026-07-01", "2026-07-05", "2026-07-06")
+	rule := excluding(t, "2026-07-05..2026-07-05")
+
+	if got := activeDays(commits, rule); got != 2 {
+		t.Errorf("activeDays = %d, want 2", got)
+	}
+	// 07-01 to 07-06 with the excluded 07-05 skipped over isn't consecutive
+	if length, start := longestStreak(commits, rule); length != 1 || !start.Equal(day(t, "2026-07-01")) {
+		t.Errorf("longestStreak = %d from %s, want 1 from 2026-07-01", length, start.Format(time.DateOnly))
+	}
+}
+
+func TestGapLeavesOutExcludedDays(t *testing.T) {
+	commits := commitsOn(t, "2026-07-01", "2026-07-10")
+	from, upto := day(t, "2026-07-01"), day(t, "2026-07-12")
+
+	// 07-02 to 07-09 is 8 days, 4 of them a vacation
+	rule := excluding(t, "2026-07-02..2026-07-05")
+	if length, start := longestGap(commits, rule, from, upto); length != 4 || !start.Equal(day(t, "2026-07-06")) {
+		t.Errorf("longestGap = %d from %s, want 4 from 2026-07-06", length, start.Format(time.DateOnly))
+	}
+	if length, start := longestGap(commits, activity{threshold: 1}, from, upto); length != 8 || !start.Equal(day(t, "2026-07-02")) {
+		t.Errorf("longestGap without excluded days = %d from %s, want 8 from 2026-07-02", length, start.Format(time.DateOnly))
+	}
+	// A vacation covering the whole break leaves no gap but the end of the window
+	rule = excluding(t, "2026-07-02..2026-07-09")
+	if length, start := longestGap(commits, rule, from, upto); length != 2 || !start.Equal(day(t, "2026-07-11")) {
+		t.Errorf("longestGap = %d from %s, want 2 from 2026-07-11", length, start.Format(time.DateOnly))
+	}
+}
+
+func TestParseExcludeRanges(t *testing.T) {
+	days, err := parseExcludeRanges([]string{"2026-07-01..2026-07-03", " 2026-12-31..2027-01-01 "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(days) != 5 || !days[day(t, "2026-07-02")] || !days[day(t, "2027-01-01")] {
+		t.Errorf("parseExcludeRanges = %v, want 07-01 to 07-03 and 12-31 to 01-01", days)
+	}
+	for _, bad := range []string{"2026-07-01", "2026-07-03..2026-07-01", "july..august"} {
+		if _, err := parseExcludeRanges([]string{bad}); err == nil {
+			t.Errorf("parseExcludeRanges(%q) didn't fail", bad)
+		}
+	}
+}
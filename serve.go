@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Requests a client may make per minute before getting 429s. Every request
+// runs git log or calls Bitbucket, so this keeps a busy dashboard from
+// hammering either.
+const requestsPerMinute = 30
+
+// Counts each client's requests in the current minute
+type rateLimiter struct {
+	mu      sync.Mutex
+	minute  time.Time
+	clients map[string]int
+}
+
+// Whether the client may make another request this minute
+func (l *rateLimiter) allow(client string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	minute := now.Truncate(time.Minute)
+	if !minute.Equal(l.minute) {
+		l.minute, l.clients = minute, make(map[string]int)
+	}
+	l.clients[client]++
+	return l.clients[client] <= requestsPerMinute
+}
+
+// Serve the API on addr until it fails. Requests start from the settings of
+// the config file and flags, the query can only narrow down who and when.
+func serve(settings Settings, addr string) error {
+	limiter := &rateLimiter{}
+	http.HandleFunc("/api/contributions", func(w http.ResponseWriter, r *http.Request) {
+		// Any origin may read the data, it's meant for custom front-ends
+		w.Header().Set("Access-Control-Allow-Origin", *corsOriginFlag)
+		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		switch r.Method {
+		case http.MethodOptions:
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case http.MethodGet:
+		default:
+			writeAPIError(w, http.StatusMethodNotAllowed, fmt.Errorf("only GET is supported"))
+			return
+		}
+
+		client, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			client = r.RemoteAddr
+		}
+		if !limiter.allow(client, time.Now()) {
+			w.Header().Set("Retry-After", strconv.Itoa(60-time.Now().Second()))
+			writeAPIError(w, http.StatusTooManyRequests, fmt.Errorf("more than %d requests a minute", requestsPerMinute))
+			return
+		}
+
+		calendar, err := contributions(settings, r)
+		if err != nil {
+			// Bad parameters are the client's fault, anything else the source's
+			status := http.StatusBadGateway
+			if exitCodeOf(err) == exitConfig {
+				status = http.StatusBadRequest
+			}
+			writeAPIError(w, status, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(calendar)
+	})
+	fmt.Printf("Serving contributions on http://%s/api/contributions\n", addr)
+	return http.ListenAndServe(addr, nil)
+}
+
+// The calendar for /api/contributions?user=&source=&since=&until= where
+// user replaces the author, source the --source and since and until are
+// YYYY-MM-DD days. Without them it's the last year, like the CLI.
+func contributions(settings Settings, r *http.Request) (calendarJSON, error) {
+	query := r.URL.Query()
+	if user := query.Get("user"); user != "" {
+		settings.Filter.Author = user
+		settings.Render.LinkAuthor = user
+		settings.User = user
+	}
+	if source := query.Get("source"); source != "" {
+		settings.Source = source
+	}
+
+	until := settings.now()
+	if value := query.Get("until"); value != "" {
+		day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			return calendarJSON{}, configError(fmt.Errorf("until must be a date like 2026-01-31, got %q", value))
+		}
+		until = endOfDay(day)
+	}
+	since := windowStart(until)
+	if value := query.Get("since"); value != "" {
+		day, err := time.ParseInLocation(time.DateOnly, value, time.Local)
+		if err != nil {
+			return calendarJSON{}, configError(fmt.Errorf("since must be a date like 2026-01-31, got %q", value))
+		}
+		since = day
+	}
+	if since.After(until) {
+		return calendarJSON{}, configError(fmt.Errorf("since is after until"))
+	}
+
+	// Nothing matching is an empty calendar to a front-end, not an error
+	history, err := fetchHistory(settings, since)
+	if err != nil && !errors.Is(err, errNoCommits) {
+		return calendarJSON{}, err
+	}
+	history.Filter = settings.Filter
+	window := Window{Start: since, End: until}
+	opts := settings.Render
+	opts.Weekly = false // The API lists days, however the terminal draws them
+	opts = opts.scaledTo(commitsInWindow(history.Commits, since, until))
+	return calendarData(newCalendar(history, window, opts)), nil
+}
+
+// Reply with the same {"error": "...", "code": N} object as --json-errors
+func writeAPIError(w http.ResponseWriter, status int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+		Code  int    `json:"code"`
+	}{err.Error(), exitCodeOf(err)})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Encode a paletted image as Sixel graphics. Palette entry 0 isn't drawn,
+// so those pixels keep the terminal's background.
+func encodeSixel(img *image.Paletted) string {
+	width, height := img.Bounds().Dx(), img.Bounds().Dy()
+	var out strings.Builder
+	// The 1 in the parameters is what leaves undrawn pixels transparent
+	out.WriteString("\x1bP0;1;0q")
+	fmt.Fprintf(&out, "\"1;1;%d;%d", width, height)
+	for i, c := range img.Palette[1:] {
+		r, g, b, _ := c.RGBA()
+		// Sixel colors are percentages
+		fmt.Fprintf(&out, "#%d;2;%d;%d;%d", i+1, r*100/0xffff, g*100/0xffff, b*100/0xffff)
+	}
+
+	// Each line of sixels covers 6 rows of pixels, drawn once per color
+	sixels := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		for index := 1; index < len(img.Palette); index++ {
+			used := false
+			for x := range width {
+				bits := byte(0)
+				for dy := range min(6, height-top) {
+					if img.ColorIndexAt(x, top+dy) == uint8(index) {
+						bits |= 1 << dy
+					}
+				}
+				used = used || bits != 0
+				sixels[x] = '?' + bits
+			}
+			if !used {
+				continue
+			}
+			fmt.Fprintf(&out, "#%d", index)
+			writeSixelRun(&out, sixels)
+			out.WriteByte('$') // Back to the start of the line for the next color
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\")
+	return out.String()
+}
+
+// Write sixels with repeats collapsed to "!count" followed by the sixel
+func writeSixelRun(out *strings.Builder, sixels []byte) {
+	for i := 0; i < len(sixels); {
+		j := i
+		for j < len(sixels) && sixels[j] == sixels[i] {
+			j++
+		}
+		if j-i > 3 {
+			fmt.Fprintf(out, "!%d%c", j-i, sixels[i])
+		} else {
+			out.Write(sixels[i:j])
+		}
+		i = j
+	}
+}
+
+// The terminal doesn't change while gitcal runs, so it's only asked once
+// instead of on every --watch reload
+var sixelSupported = sync.OnceValue(supportsSixel)
+
+// Ask the terminal for its primary device attributes, terminals that can
+// draw Sixel graphics include a 4 in the reply, e.g. "\x1b[?62;4;22c".
+// Terminals that don't answer within a second are taken as not supporting it.
+func supportsSixel() bool {
+	if !term.IsTerminal(os.Stdin.Fd()) || !term.IsTerminal(os.Stdout.Fd()) {
+		return false
+	}
+	state, err := term.MakeRaw(os.Stdin.Fd())
+	if err != nil {
+		return false
+	}
+	defer term.Restore(os.Stdin.Fd(), state)
+
+	fmt.Print("\x1b[c")
+	answer, ok := readTerminalReply('c', time.Second)
+	if !ok {
+		return false
+	}
+	params, ok := strings.CutPrefix(strings.TrimSuffix(answer, "c"), "\x1b[?")
+	if !ok {
+		return false
+	}
+	// The first value is the terminal's class, the rest its features
+	fields := strings.Split(params, ";")
+	for _, field := range fields[1:] {
+		if field == "4" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,42 @@
+//go:build !windows
+
+package main
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Read a terminal's reply to a query from stdin up to and including end,
+// giving up after timeout. A byte is only read once poll says it's there,
+// so no read is left waiting on stdin to swallow what the user types next.
+func readTerminalReply(end byte, timeout time.Duration) (string, bool) {
+	fd := int(os.Stdin.Fd())
+	deadline := time.Now().Add(timeout)
+	reply := make([]byte, 0)
+	b := make([]byte, 1)
+	for {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return string(reply), false
+		}
+		fds := []unix.PollFd{{Fd: int32(fd), Events: unix.POLLIN}}
+		ready, err := unix.Poll(fds, int(wait.Milliseconds())+1)
+		if errors.Is(err, unix.EINTR) {
+			continue
+		}
+		if err != nil || ready == 0 {
+			return string(reply), false
+		}
+		if n, err := unix.Read(fd, b); err != nil || n == 0 {
+			return string(reply), false
+		}
+		reply = append(reply, b[0])
+		if b[0] == end {
+			return string(reply), true
+		}
+	}
+}
@@ -0,0 +1,38 @@
+//go:build windows
+
+package main
+
+import (
+	"os"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// Read a terminal's reply to a query from stdin up to and including end,
+// giving up after timeout. A byte is only read once the console has input,
+// so no read is left waiting on stdin to swallow what the user types next.
+func readTerminalReply(end byte, timeout time.Duration) (string, bool) {
+	handle := windows.Handle(os.Stdin.Fd())
+	deadline := time.Now().Add(timeout)
+	reply := make([]byte, 0)
+	b := make([]byte, 1)
+	for {
+		wait := time.Until(deadline)
+		if wait <= 0 {
+			return string(reply), false
+		}
+		event, err := windows.WaitForSingleObject(handle, uint32(wait.Milliseconds())+1)
+		if err != nil || event != windows.WAIT_OBJECT_0 {
+			return string(reply), false
+		}
+		var n uint32
+		if err := windows.ReadFile(handle, b, &n, nil); err != nil || n == 0 {
+			return string(reply), false
+		}
+		reply = append(reply, b[0])
+		if b[0] == end {
+			return string(reply), true
+		}
+	}
+}
@@ -0,0 +1,398 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Number of commits on each day by their weight, keyed by dayOf. Each day
+// is rounded, so half a merge still counts as one.
+func dailyCounts(commits []Commit) map[time.Time]int {
+	weights := make(map[time.Time]float64)
+	for _, commit := range commits {
+		weights[dayOf(commit.Timestamp)] += commit.Weight
+	}
+	counts := make(map[time.Time]int, len(weights))
+	for day, weight := range weights {
+		counts[day] = int(math.Round(weight))
+	}
+	return counts
+}
+
+// Number of commits by their weight, e.g. for totals
+func weightedTotal(commits []Commit) int {
+	total := 0.0
+	for _, commit := range commits {
+		total += commit.Weight
+	}
+	return int(math.Round(total))
+}
+
+// Value of each day for a metric, keyed by dayOf: the number of commits or
+// the lines added plus deleted
+func dailyValues(commits []Commit, metric string) map[time.Time]int {
+	if metric != metricLines {
+		return dailyCounts(commits)
+	}
+	values := make(map[time.Time]int)
+	for _, commit := range commits {
+		values[dayOf(commit.Timestamp)] += commit.Additions + commit.Deletions
+	}
+	return values
+}
+
+// Highest value of any day for a metric
+func busiestValue(commits []Commit, metric string) int {
+	busiest := 0
+	for _, value := range dailyValues(commits, metric) {
+		busiest = max(busiest, value)
+	}
+	return busiest
+}
+
+// Largest total of a week, weeks starting on weekStart like the columns of
+// the grid
+func busiestWeek(commits []Commit, metric string) int {
+	totals := make(map[time.Time]int)
+	busiest := 0
+	for day, value := range dailyValues(commits, metric) {
+		week := weekOf(day)
+		totals[week] += value
+		busiest = max(busiest, totals[week])
+	}
+	return busiest
+}
+
+// Days with at least the rule's threshold of commits that aren't excluded,
+// in order. Only these count as active for streaks, active days and gaps.
+func activeDayList(commits []Commit, rule activity) []time.Time {
+	days := make([]time.Time, 0)
+	for day, count := range dailyCounts(commits) {
+		if count >= rule.threshold && !rule.excluded[day] {
+			days = append(days, day)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// Number of distinct active days, see activeDayList
+func activeDays(commits []Commit, rule activity) int {
+	return len(activeDayList(commits, rule))
+}
+
+// Longest run of consecutive active days, returns its length and the first
+// day of the run. The earliest run wins a tie.
+func longestStreak(commits []Commit, rule activity) (int, time.Time) {
+	days := activeDayList(commits, rule)
+
+	best, bestStart := 0, time.Time{}
+	length, start := 0, time.Time{}
+	for i, day := range days {
+		// Excluded days in between don't break the streak
+		if i > 0 && rule.next(days[i-1]).Equal(day) {
+			length++
+		} else {
+			length, start = 1, day
+		}
+		if length > best {
+			best, bestStart = length, start
+		}
+	}
+	return best, bestStart
+}
+
+// Longest run of inactive days within [startDate, uptoDate], including the
+// stretches before the first and after the last active day. Returns its
+// length and first day, the earliest gap wins a tie. Excluded days are left
+// out of the length.
+func longestGap(commits []Commit, rule activity, startDate, uptoDate time.Time) (int, time.Time) {
+	// Walk the active days with a sentinel just outside each end of the window
+	first, last := dayOf(startDate), dayOf(uptoDate)
+	days := append([]time.Time{first.AddDate(0, 0, -1)}, activeDayList(commits, rule)...)
+	days = append(days, last.AddDate(0, 0, 1))
+
+	best, bestStart := 0, time.Time{}
+	for i := 1; i < len(days); i++ {
+		gap := rule.days(days[i-1].AddDate(0, 0, 1), days[i].AddDate(0, 0, -1))
+		if gap > best {
+			best, bestStart = gap, rule.next(days[i-1])
+		}
+	}
+	return best, bestStart
+}
+
+// Day with the most commits and how many, the earliest day wins a tie
+func peakDay(commits []Commit) (time.Time, int) {
+	peak, peakCount := time.Time{}, 0
+	for day, count := range dailyCounts(commits) {
+		if count > peakCount || (count == peakCount && day.Before(peak)) {
+			peak, peakCount = day, count
+		}
+	}
+	return peak, peakCount
+}
+
+// Total commits on each weekday by their weight, indexed by time.Weekday
+func weekdayCounts(commits []Commit) [7]int {
+	var weights [7]float64
+	for _, commit := range commits {
+		weights[commit.Timestamp.Weekday()] += commit.Weight
+	}
+	var counts [7]int
+	for day, weight := range weights {
+		counts[day] = int(math.Round(weight))
+	}
+	return counts
+}
+
+// Added and deleted lines over the commits. Every commit counts fully, the
+// same as for commit counts, unless split is set: then a commit with
+// co-authors only counts its share, 1/(co-authors+1) of its lines.
+func lineTotals(commits []Commit, split bool) (float64, float64) {
+	added, deleted := 0.0, 0.0
+	for _, commit := range commits {
+		weight := 1.0
+		if split {
+			weight /= float64(len(commit.Coauthors) + 1)
+		}
+		added += float64(commit.Additions) * weight
+		deleted += float64(commit.Deletions) * weight
+	}
+	return added, deleted
+}
+
+// Weekday with the most commits, the first from weekStart wins a tie
+func busiestWeekday(commits []Commit) time.Weekday {
+	counts := weekdayCounts(commits)
+	busiest := weekStart
+	for i := range time.Weekday(rows) {
+		day := (weekStart + i) % 7
+		if counts[day] > counts[busiest] {
+			busiest = day
+		}
+	}
+	return busiest
+}
+
+// Singular or plural form of a unit for a count
+func plural(count int, unit string) string {
+	if count == 1 {
+		return fmt.Sprintf("%d %s", count, unit)
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// Describe the window's activity in one sentence, e.g. for a README or a
+// status update. Days need the rule's threshold of commits to count as
+// active, excluded days don't count towards the window's length.
+func summarySentence(commits []Commit, startDate, uptoDate time.Time, rule activity) string {
+	if len(commits) == 0 {
+		return "No commits in the selected window."
+	}
+	windowDays := rule.days(startDate, uptoDate)
+	active := activeDays(commits, rule)
+	if active == 0 {
+		return fmt.Sprintf("No day in the last %d days had %s.", windowDays, plural(rule.threshold, "commit"))
+	}
+	streak, streakStart := longestStreak(commits, rule)
+	gap, _ := longestGap(commits, rule, startDate, uptoDate)
+	peak, peakCount := peakDay(commits)
+
+	// Name the month when the streak fits in one, otherwise give its range
+	streakEnd := streakStart
+	for range streak - 1 {
+		streakEnd = rule.next(streakEnd)
+	}
+	when := "in " + streakStart.Month().String()
+	if streakEnd.Month() != streakStart.Month() {
+		when = fmt.Sprintf("from %s to %s", streakStart.Format("January 2"), streakEnd.Format("January 2"))
+	}
+
+	// Only mention the threshold when it isn't the obvious one commit
+	committed := "committed"
+	if rule.threshold > 1 {
+		committed = fmt.Sprintf("made %d or more commits", rule.threshold)
+	}
+	return fmt.Sprintf("You %s on %d of the last %d days, with your longest streak of %s %s, a longest break of %s and a peak of %s on %s.",
+		committed, active, windowDays, plural(streak, "day"), when, plural(gap, "day"), plural(peakCount, "commit"), peak.Format("January 2"))
+}
+
+// Most recent commit's timestamp, the zero time without commits
+func lastCommit(commits []Commit) time.Time {
+	last := time.Time{}
+	for _, commit := range commits {
+		if commit.Timestamp.After(last) {
+			last = commit.Timestamp
+		}
+	}
+	return last
+}
+
+// How long before now t was, in hours under a day and in days after that
+func timeAgo(t, now time.Time) string {
+	elapsed := now.Sub(t)
+	switch {
+	case elapsed < time.Hour:
+		return "less than an hour ago" // Or clock skew put it in the future
+	case elapsed < 24*time.Hour:
+		return plural(int(elapsed.Hours()), "hour") + " ago"
+	default:
+		return plural(int(elapsed.Hours()/24), "day") + " ago"
+	}
+}
+
+// Stats --stats can show
+var statNames = []string{"total", "active", "streak", "longest", "break", "peak", "weekday", "last", "releases"}
+
+// Shown when neither --stats-fields nor the config picks any
+var defaultStatsFields = []string{"total", "active", "streak", "longest", "last"}
+
+// Check stat names against statNames
+func validateStatsFields(fields []string) error {
+	for _, field := range fields {
+		if !slices.Contains(statNames, field) {
+			return fmt.Errorf("unknown stat %q, use any of %s", field, strings.Join(statNames, ","))
+		}
+	}
+	return nil
+}
+
+// Run of active days up to uptoDate. A streak is still current when the
+// last day has no commits yet, so it counts back from the day before then.
+// Excluded days are skipped over.
+func currentStreak(commits []Commit, rule activity, uptoDate time.Time) int {
+	active := make(map[time.Time]bool)
+	for _, day := range activeDayList(commits, rule) {
+		active[day] = true
+	}
+	day := rule.previous(dayOf(uptoDate).AddDate(0, 0, 1))
+	if !active[day] {
+		day = rule.previous(day)
+	}
+	streak := 0
+	for ; active[day]; day = rule.previous(day) {
+		streak++
+	}
+	return streak
+}
+
+// Summary below the calendar for --stats, one line per field in the order
+// given. commits are the ones in [startDate, uptoDate], while the last
+// commit is looked up in all of history so a quiet window still shows when
+// work last happened. tags are all of the releases, nil if they weren't read.
+func statsBlock(fields []string, commits, history []Commit, tags []Tag, startDate, uptoDate, now time.Time, rule activity) string {
+	block := ""
+	line := func(label, value string) {
+		block += fmt.Sprintf("%-17s%s\n", label+":", value)
+	}
+	for _, field := range fields {
+		switch field {
+		case "total":
+			line("Contributions", strconv.Itoa(weightedTotal(commits)))
+		case "active":
+			windowDays := rule.days(startDate, uptoDate)
+			line("Active days", fmt.Sprintf("%d of %d", activeDays(commits, rule), windowDays))
+		case "streak":
+			line("Current streak", plural(currentStreak(commits, rule, uptoDate), "day"))
+		case "longest":
+			if streak, streakStart := longestStreak(commits, rule); streak > 0 {
+				line("Longest streak", fmt.Sprintf("%s from %s", plural(streak, "day"), streakStart.Format("Jan 2, 2006")))
+			} else {
+				line("Longest streak", "0 days")
+			}
+		case "break":
+			gap, _ := longestGap(commits, rule, startDate, uptoDate)
+			line("Longest break", plural(gap, "day"))
+		case "peak":
+			if peak, peakCount := peakDay(commits); peakCount > 0 {
+				line("Peak day", fmt.Sprintf("%s on %s", plural(peakCount, "commit"), peak.Format("Jan 2, 2006")))
+			} else {
+				line("Peak day", "none")
+			}
+		case "weekday":
+			busiest, counts := busiestWeekday(commits), weekdayCounts(commits)
+			line("Busiest weekday", fmt.Sprintf("%s (%s)", busiest, plural(counts[busiest], "commit")))
+		case "releases":
+			if tags == nil {
+				line("Releases", "not read, see --show-tags")
+			} else {
+				line("Releases", strconv.Itoa(len(tagsInWindow(tags, startDate, uptoDate))))
+			}
+		case "last":
+			if last := lastCommit(history); last.IsZero() {
+				line("Last commit", "never")
+			} else {
+				line("Last commit", timeAgo(last, now))
+			}
+		}
+	}
+	return block
+}
+
+// Separator between the parts of --compact-stats
+const compactSeparator = " · "
+
+// The window's stats on one line, e.g. "1290 commits · 142 active days ·
+// 12-day streak · peak Tue". With a width above 0 parts are dropped from
+// the end until the line fits, so it never wraps halfway through a stat.
+func compactStats(commits []Commit, rule activity, width int) string {
+	parts := compactParts(commits, rule)
+	line := strings.Join(parts, compactSeparator)
+	for width > 0 && len(parts) > 1 && utf8.RuneCountInString(line) > width {
+		parts = parts[:len(parts)-1]
+		line = strings.Join(parts, compactSeparator)
+	}
+	return line
+}
+
+// Commits, active days, longest streak and the peak weekday when there are
+// any commits, most important first
+func compactParts(commits []Commit, rule activity) []string {
+	streak, _ := longestStreak(commits, rule)
+	parts := []string{
+		plural(weightedTotal(commits), "commit"),
+		plural(activeDays(commits, rule), "active day"),
+		fmt.Sprintf("%d-day streak", streak),
+	}
+	if len(commits) > 0 {
+		parts = append(parts, "peak "+busiestWeekday(commits).String()[:3])
+	}
+	return parts
+}
+
+// How far the window is from target contributions for --target-total. The
+// goal pattern is an evenly filled calendar: target spread over every day,
+// days below that pace are what's missing from the picture. This only
+// reports numbers, it never makes commits.
+func targetReport(commits []Commit, target int, startDate, uptoDate time.Time) string {
+	windowDays := int(dayOf(uptoDate).Sub(dayOf(startDate)).Hours()/24) + 1
+	total := weightedTotal(commits)
+	if total >= target {
+		return fmt.Sprintf("Target reached: %d of %d contributions.\n", total, target)
+	}
+
+	// Round up so the pace always adds up to at least the target
+	pace := (target + windowDays - 1) / windowDays
+	counts := dailyCounts(commits)
+	below, missing := 0, 0
+	for day := dayOf(startDate); !day.After(dayOf(uptoDate)); day = day.AddDate(0, 0, 1) {
+		if counts[day] < pace {
+			below++
+			missing += pace - counts[day]
+		}
+	}
+
+	report := fmt.Sprintf("%-17s%d contributions\n", "Target:", target)
+	report += fmt.Sprintf("%-17s%d\n", "Current:", total)
+	report += fmt.Sprintf("%-17s%d\n", "Short by:", target-total)
+	report += fmt.Sprintf("%-17s%s a day over %s\n", "Even pace:", plural(pace, "commit"), plural(windowDays, "day"))
+	report += fmt.Sprintf("%-17s%s, missing %s to reach the pace\n", "Below the pace:", plural(below, "day"), plural(missing, "commit"))
+	return report
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActiveThreshold(t *testing.T) {
+	// 3, 1, 3, 4, 0 and 2 commits from 07-01 to 07-06
+	commits := commitsOn(t,
+		"2026-07-01", "2026-07-01", "2026-07-01",
+		"2026-07-02",
+		"2026-07-03", "2026-07-03", "2026-07-03",
+		"2026-07-04", "2026-07-04", "2026-07-04", "2026-07-04",
+		"2026-07-06", "2026-07-06",
+	)
+	from, upto := day(t, "2026-07-01"), day(t, "2026-07-08")
+	tests := []struct {
+		threshold   int
+		active      int
+		streak      int
+		streakStart string
+		gap         int
+		gapStart    string
+	}{
+		{threshold: 1, active: 5, streak: 4, streakStart: "2026-07-01", gap: 2, gapStart: "2026-07-07"},
+		{threshold: 3, active: 3, streak: 2, streakStart: "2026-07-03", gap: 4, gapStart: "2026-07-05"},
+	}
+	for _, test := range tests {
+		rule := activity{threshold: test.threshold}
+		if got := activeDays(commits, rule); got != test.active {
+			t.Errorf("threshold %d: activeDays = %d, want %d", test.threshold, got, test.active)
+		}
+		if length, start := longestStreak(commits, rule); length != test.streak || !start.Equal(day(t, test.streakStart)) {
+			t.Errorf("threshold %d: longestStreak = %d from %s, want %d from %s",
+				test.threshold, length, start.Format(time.DateOnly), test.streak, test.streakStart)
+		}
+		if length, start := longestGap(commits, rule, from, upto); length != test.gap || !start.Equal(day(t, test.gapStart)) {
+			t.Errorf("threshold %d: longestGap = %d from %s, want %d from %s",
+				test.threshold, length, start.Format(time.DateOnly), test.gap, test.gapStart)
+		}
+	}
+}
+
+func TestActiveThresholdCountsMergesByWeight(t *testing.T) {
+	commits := commitsOn(t, "2026-07-01", "2026-07-01", "2026-07-01")
+	commits[2].Parents = 2
+	weighMerges(commits, mergeWeights["zero"])
+
+	if got := activeDays(commits, activity{threshold: 3}); got != 0 {
+		t.Errorf("activeDays with a zero weight merge = %d, want 0", got)
+	}
+	if got := activeDays(commits, activity{threshold: 2}); got != 1 {
+		t.Errorf("activeDays = %d, want 1", got)
+	}
+}
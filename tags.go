@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// An annotated tag, i.e. a release. Lightweight tags have no tagger or date
+// of their own and are left out.
+type Tag struct {
+	Name        string
+	Tagger      string
+	TaggerEmail string
+	Timestamp   time.Time
+}
+
+// Read the annotated tags whose tagger matches the filter's author, or its
+// committer without an author, the same way commits are matched
+func runGitTags(filter LogFilter) ([]Tag, error) {
+	pattern := filter.Author
+	if pattern == "" {
+		pattern = filter.Committer
+	}
+	identity, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid author pattern %q: %v", pattern, err)
+	}
+
+	output, err := runGit("for-each-ref", "refs/tags",
+		"--format=%(objecttype)%09%(refname:short)%09%(taggername)%09%(taggeremail)%09%(taggerdate:iso-strict)")
+	if err != nil {
+		return nil, err
+	}
+	tags := make([]Tag, 0)
+	for _, line := range strings.Split(string(output), "\n") {
+		parts := strings.Split(line, "\t")
+		if len(parts) != 5 || parts[0] != "tag" {
+			continue // Lightweight tags point straight at a commit
+		}
+		// The email already comes in angle brackets
+		if !identity.MatchString(parts[2] + " " + parts[3]) {
+			continue
+		}
+		date, err := time.Parse(time.RFC3339, parts[4])
+		if err != nil {
+			continue // Very old tags can lack a date
+		}
+		tags = append(tags, Tag{
+			Name:        parts[1],
+			Tagger:      parts[2],
+			TaggerEmail: strings.Trim(parts[3], "<>"),
+			Timestamp:   date,
+		})
+	}
+	return tags, nil
+}
+
+// Tags whose day falls within [startDate, uptoDate]
+func tagsInWindow(tags []Tag, startDate, uptoDate time.Time) []Tag {
+	first, last := dayOf(startDate), dayOf(uptoDate)
+	inWindow := make([]Tag, 0)
+	for _, tag := range tags {
+		day := dayOf(tag.Timestamp)
+		if !day.Before(first) && !day.After(last) {
+			inWindow = append(inWindow, tag)
+		}
+	}
+	return inWindow
+}
+
+// Days with at least one of the tags, for RenderOptions.TagDays
+func tagDays(tags []Tag) map[time.Time]bool {
+	days := make(map[time.Time]bool)
+	for _, tag := range tags {
+		days[dayOf(tag.Timestamp)] = true
+	}
+	return days
+}
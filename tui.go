@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+var panelStyle = lipgloss.NewStyle().
+	Border(lipgloss.NormalBorder()).
+	BorderForeground(lipgloss.Color("#ffffff")).
+	Padding(0, 1).
+	Width(panelWidth)
+
+const (
+	panelWidth   = 48
+	panelCommits = 8 // Commits listed at once, the rest is reached by scrolling
+)
+
+// Interactive calendar, a cursor moves over the days of the window and a side
+// panel shows what happened on the focused day. [ and ] flip between windows,
+// which only re-slices the commits parsed at startup so it's instant.
+type tuiModel struct {
+	history   CommitHistory
+	opts      RenderOptions
+	now       time.Time // End of the most recent window
+	offset    int       // Windows back from now, 0 is the current one
+	uptoDate  time.Time
+	matrix    [][]int
+	gridStart time.Time
+	byDay     map[time.Time][]Commit
+	row, col  int // Focused cell in the matrix
+	scroll    int // First commit listed for the focused day
+}
+
+func newTUIModel(history CommitHistory, uptoDate time.Time, opts RenderOptions) tuiModel {
+	byDay := make(map[time.Time][]Commit)
+	for _, commit := range history.Commits {
+		day := dayOf(commit.Timestamp)
+		byDay[day] = append(byDay[day], commit)
+	}
+	// The side panel already shows each day's commits, links aren't needed
+	opts.LinkURL = ""
+	// One scale over all of history keeps colors comparable while paging
+	opts = opts.scaledTo(history.Commits)
+	if history.Tags != nil {
+		opts.TagDays = tagDays(history.Tags)
+	}
+	m := tuiModel{history: history, opts: opts, now: uptoDate, byDay: byDay}
+	m.load(0)
+
+	// Start on the last day of the window, i.e. today
+	days := int(dayOf(uptoDate).Sub(m.gridStart).Hours() / 24)
+	m.row, m.col = days%rows, days/rows
+	return m
+}
+
+// Show the window offset windows back from now. The focused weekday is kept
+// and the week is moved to the nearest one that has that day.
+func (m *tuiModel) load(offset int) {
+	m.offset = offset
+	m.uptoDate = m.now.AddDate(0, 0, -offset*rows*columns)
+	startDate := windowStart(m.uptoDate)
+	commits := commitsInWindow(m.history.Commits, startDate, m.uptoDate)
+	m.matrix, m.gridStart = buildMatrix(commits, Window{Start: startDate, End: m.uptoDate}, m.opts.Metric)
+
+	weeks := len(m.matrix[0])
+	m.col = min(m.col, weeks-1)
+	if m.matrix[m.row][m.col] == noDay {
+		if m.col == 0 {
+			m.col++
+		} else {
+			m.col--
+		}
+	}
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	row, col := m.row, m.col
+	switch key.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	case "left", "h":
+		col--
+	case "right", "l":
+		col++
+	case "up", "k":
+		row--
+	case "down", "j":
+		row++
+	case "[":
+		m.load(m.offset + 1)
+		m.scroll = 0
+		return m, nil
+	case "]":
+		if m.offset > 0 {
+			m.load(m.offset - 1)
+			m.scroll = 0
+		}
+		return m, nil
+	case "pgdown", "J":
+		if m.scroll+panelCommits < len(m.byDay[m.focusedDay()]) {
+			m.scroll++
+		}
+		return m, nil
+	case "pgup", "K":
+		if m.scroll > 0 {
+			m.scroll--
+		}
+		return m, nil
+	}
+	// Only move onto real days, padding slots have nothing to show
+	if row >= 0 && row < rows && col >= 0 && col < len(m.matrix[0]) && m.matrix[row][col] != noDay {
+		m.row, m.col = row, col
+		m.scroll = 0
+	}
+	return m, nil
+}
+
+// Date of the focused cell
+func (m tuiModel) focusedDay() time.Time {
+	return m.gridStart.AddDate(0, 0, m.col*rows+m.row)
+}
+
+func (m tuiModel) View() string {
+	title := fmt.Sprintf("%s – %s", monthYear(windowStart(m.uptoDate), m.opts), monthYear(m.uptoDate, m.opts))
+	grid := title + "\n"
+	if !m.opts.NoMonths {
+		grid += monthHeader(m.gridStart, len(m.matrix[0]), m.opts) + "\n"
+	}
+	grid += m.opts.box().Render(renderGrid(m.matrix, m.gridStart, m.opts, m.row, m.col))
+	if m.opts.FooterDate {
+		grid += "\n" + footerLine(m.history.Commits, m.focusedDay(), m.opts)
+	}
+
+	day := m.focusedDay()
+	commits := m.byDay[day]
+	panel := m.opts.dayName(day) + "\n" + plural(len(commits), "commit") + "\n"
+	if m.scroll > 0 {
+		panel += fmt.Sprintf("\n↑ %d more", m.scroll)
+	}
+	end := min(m.scroll+panelCommits, len(commits))
+	for _, commit := range commits[m.scroll:end] {
+		line := fmt.Sprintf("%s %s %s", commit.Hash, commit.Timestamp.Format("15:04"), commit.Subject)
+		// Keep each commit on one line, the panel adds 2 columns of padding
+		if runes := []rune(line); len(runes) > panelWidth-2 {
+			line = string(runes[:panelWidth-3]) + "…"
+		}
+		panel += "\n" + line
+	}
+	if end < len(commits) {
+		panel += fmt.Sprintf("\n↓ %d more", len(commits)-end)
+	}
+
+	panelBox := panelStyle
+	if m.opts.ASCII {
+		panelBox = panelBox.Border(lipgloss.ASCIIBorder())
+	}
+	return m.opts.encode(lipgloss.JoinHorizontal(lipgloss.Top, grid, " ", panelBox.Render(panel)) +
+		"\n\narrows/hjkl move • [/] previous/next year • J/K scroll commits • q quit\n")
+}
+
+// Run the interactive calendar until the user quits
+func runTUI(history CommitHistory, uptoDate time.Time, opts RenderOptions) error {
+	_, err := tea.NewProgram(newTUIModel(history, uptoDate, opts), tea.WithAltScreen()).Run()
+	return err
+}